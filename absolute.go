@@ -0,0 +1,83 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+var ErrBitOffset = errors.New("deltagolomb: bit offset out of range")
+
+// EncodeAbsolute Exp-Golomb codes values directly, with no delta
+// stage, so each value can be decoded on its own.  Returns the bit
+// offset at which each value's codeword starts; any of these can be
+// handed to DecodeAbsoluteAt for random access.
+func EncodeAbsolute(w io.Writer, values []int) []int {
+	offsets := make([]int, len(values))
+	egs := NewExpGolombEncoder(w)
+	pos := 0
+	for i, v := range values {
+		offsets[i] = pos
+		pos += codeLen(v)
+		egs.WriteInt(v)
+	}
+	egs.Close()
+	return offsets
+}
+
+// DecodeAbsolute decodes every value in a stream written by
+// EncodeAbsolute.
+func DecodeAbsolute(compressed []byte) []int {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(bytes.NewBuffer(compressed))
+	tmp := make([]int, 256)
+	for {
+		n, err := decoder.Read(tmp)
+		res = append(res, tmp[:n]...)
+		if err != nil {
+			return res
+		}
+	}
+}
+
+// DecodeAbsoluteAt decodes the single value whose codeword starts
+// bitOffset bits into compressed, as reported by EncodeAbsolute.
+func DecodeAbsoluteAt(compressed []byte, bitOffset int) (int, error) {
+	v, _, err := DecodeValueAt(compressed, bitOffset)
+	return v, err
+}
+
+// DecodeValueAt decodes the one codeword starting bitOffset bits into
+// data and returns its value along with the offset of the codeword
+// after it.  Codewords may start at any bit and span bytes.  If only
+// zero padding remains at bitOffset the error is io.EOF; a codeword
+// cut off by the end of data gives io.ErrUnexpectedEOF.
+func DecodeValueAt(data []byte, bitOffset int) (value int, nextBitOffset int, err error) {
+	if bitOffset < 0 || bitOffset > 8*len(data) {
+		return 0, bitOffset, ErrBitOffset
+	}
+	decoder := NewExpGolombDecoder(bytes.NewReader(data[bitOffset/8:]))
+	for i := 0; i < bitOffset%8; i++ {
+		decoder.readBit()
+	}
+	tmp := make([]int, 1)
+	if n, err := decoder.Read(tmp); n == 0 {
+		if err == io.EOF && decoder.truncated() {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, bitOffset, err
+	}
+	return tmp[0], bitOffset + codeLen(tmp[0]), nil
+}
+
+// codeLen returns the length in bits of item's sign-bit codeword.
+func codeLen(item int) int {
+	if item == 0 {
+		return 1
+	}
+	if item < 0 {
+		item = -item
+	}
+	nbits := bitLen(uint(item)+1) - 1
+	return 2*nbits + 2 // zeros, high order bit, nbits, sign
+}
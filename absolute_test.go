@@ -0,0 +1,108 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestCodeLen(t *testing.T) {
+	for i := -70000; i <= 70000; i++ {
+		buf := &bytes.Buffer{}
+		e := NewExpGolombEncoder(buf)
+		e.WriteInt(i)
+		bits := int(egWordBits - e.bitsleft)
+		if codeLen(i) != bits {
+			t.Fatalf("codeLen(%d) = %d, encoder used %d bits", i, codeLen(i), bits)
+		}
+	}
+}
+
+func TestEncodeAbsolute(t *testing.T) {
+	values := make([]int, 1000)
+	for i := range values {
+		values[i] = rand.Intn(41) - 20
+	}
+	buf := &bytes.Buffer{}
+	offsets := EncodeAbsolute(buf, values)
+	compressed := buf.Bytes()
+
+	res := DecodeAbsolute(compressed)
+	if len(res) != len(values) {
+		t.Fatalf("Got %d values, want %d", len(res), len(values))
+	}
+	for i := range values {
+		if res[i] != values[i] {
+			t.Fatalf("item %d was %d, expected %d", i, res[i], values[i])
+		}
+	}
+	// Visit in random order to make sure nothing depends on history.
+	for _, i := range rand.Perm(len(values)) {
+		v, err := DecodeAbsoluteAt(compressed, offsets[i])
+		if err != nil {
+			t.Fatalf("DecodeAbsoluteAt(%d): %v", offsets[i], err)
+		}
+		if v != values[i] {
+			t.Fatalf("DecodeAbsoluteAt item %d was %d, expected %d", i, v, values[i])
+		}
+	}
+	if _, err := DecodeAbsoluteAt(compressed, 8*len(compressed)+1); err != ErrBitOffset {
+		t.Fatal("Expected ErrBitOffset past the end, got ", err)
+	}
+}
+
+// Absolute coding wins on small unrelated values; delta coding wins
+// on a slowly varying signal far from zero.
+func TestAbsoluteSizeTradeoff(t *testing.T) {
+	noise := make([]int, 1000)
+	for i := range noise {
+		noise[i] = rand.Intn(7) - 3
+	}
+	smooth := make([]int, 1000)
+	for i := range smooth {
+		smooth[i] = 100000 + i
+	}
+
+	absSize := func(v []int) int {
+		buf := &bytes.Buffer{}
+		EncodeAbsolute(buf, v)
+		return buf.Len()
+	}
+	if a, d := absSize(noise), len(DeltaEncode(0, noise)); a >= d {
+		t.Errorf("Noise: absolute %d bytes, delta %d; expected absolute smaller", a, d)
+	}
+	if a, d := absSize(smooth), len(DeltaEncode(0, smooth)); a <= d {
+		t.Errorf("Smooth: absolute %d bytes, delta %d; expected delta smaller", a, d)
+	}
+}
+
+func TestDecodeValueAt(t *testing.T) {
+	data := []int{0, 5, -5, 1 << 33, 17, -(1 << 62), 0, 0, 3, 1<<63 - 1}
+	base := -9
+	compressed := DeltaEncode(base, data)
+	want := DeltaDecode(base, compressed)
+
+	offset, last, val := 0, 0, base
+	for i := range want {
+		v, next, err := DecodeValueAt(compressed, offset)
+		if err != nil {
+			t.Fatalf("item %d at bit %d: %v", i, offset, err)
+		}
+		if next <= offset {
+			t.Fatalf("item %d: next offset %d does not advance from %d", i, next, offset)
+		}
+		val += v
+		if val != want[i] {
+			t.Fatalf("item %d was %d, expected %d", i, val, want[i])
+		}
+		last, offset = offset, next
+	}
+	if _, _, err := DecodeValueAt(compressed, offset); err != io.EOF {
+		t.Fatal("Expected io.EOF in the padding, got ", err)
+	}
+	// Cut the final 126-bit codeword short.
+	if _, _, err := DecodeValueAt(compressed[:len(compressed)-4], last); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF for a truncated codeword, got ", err)
+	}
+}
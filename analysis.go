@@ -0,0 +1,166 @@
+package deltagolomb
+
+import (
+	"io"
+	"math"
+	"sort"
+)
+
+// Entropy returns the order-zero empirical entropy of values in bits
+// per value:  the lower bound for any coder that codes each value
+// independently using their observed frequencies.  To measure delta
+// residuals, pass Deltas(start, data).  Empty input has entropy 0.
+func Entropy(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	counts := make(map[int]int)
+	for _, v := range values {
+		counts[v]++
+	}
+	n := float64(len(values))
+	h := 0.0
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	// Rounding can leave a degenerate distribution at -0.
+	if h < 0 {
+		h = 0
+	}
+	return h
+}
+
+// EncodedLen returns the number of bits Exp-Golomb needs for values,
+// excluding the padding at the end of the stream.  Divided by
+// len(values) it gives the realized bits per value to set against
+// Entropy.
+func EncodedLen(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += codeLen(v)
+	}
+	return total
+}
+
+// MaxCodeLen returns the length in bits of the longest sign-bit
+// codeword for any value in [minVal, maxVal], for sizing buffers.
+// Codeword length grows with magnitude, so it is the longer of the
+// codewords for the two ends.  An empty range, minVal > maxVal,
+// gives 0.
+func MaxCodeLen(minVal, maxVal int) int {
+	if minVal > maxVal {
+		return 0
+	}
+	if l := codeLen(minVal); l > codeLen(maxVal) {
+		return l
+	}
+	return codeLen(maxVal)
+}
+
+// Codeword describes the parts of a sign-bit codeword, in the order
+// they are written:  LeadingZeros zero bits, the Prefix bit, which is
+// always 1, SuffixBits bits of Suffix, and the sign.  Prefix and
+// Suffix together are |v|+1 in binary.  Sign is the sign bit, 0 for
+// positive and 1 for negative, or -1 for zero, which has none.
+type Codeword struct {
+	LeadingZeros int
+	Prefix       uint
+	Suffix       uint
+	SuffixBits   int
+	Sign         int
+}
+
+// Len returns the length of the codeword in bits.
+func (c Codeword) Len() int {
+	if c.Sign < 0 {
+		return c.LeadingZeros + 1 + c.SuffixBits
+	}
+	return c.LeadingZeros + 1 + c.SuffixBits + 1
+}
+
+// CodewordParts returns the parts of v's sign-bit codeword, as
+// ExpGolombEncoder writes it.
+func CodewordParts(v int) Codeword {
+	if v == 0 {
+		return Codeword{Prefix: 1, Sign: -1}
+	}
+	mag := uint(v)
+	sign := 0
+	if v < 0 {
+		mag = -mag
+		sign = 1
+	}
+	nbits := bitLen(mag+1) - 1
+	return Codeword{
+		LeadingZeros: nbits,
+		Prefix:       1,
+		Suffix:       (mag + 1) & (1<<uint(nbits) - 1),
+		SuffixBits:   nbits,
+		Sign:         sign,
+	}
+}
+
+// ChooseBase returns the start for DeltaEncode(start, values) that
+// gives the smallest encoding.  Only the first residual depends on
+// the start, so it is values[0], making that residual a one-bit
+// zero.  Empty input gives 0.
+func ChooseBase(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	return values[0]
+}
+
+// ChooseOffset returns an offset to subtract from values before
+// coding them directly, as EncodeAbsolute does, so that they centre
+// on zero.  It is the median (the lower one for even lengths), which
+// minimises the total magnitude; since codeword length grows with the
+// logarithm of the magnitude this is close to, though not always
+// exactly, the smallest encoding.  Empty input gives 0.
+func ChooseOffset(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+	return sorted[(len(sorted)-1)/2]
+}
+
+// DecodeWithLengths decodes a stream of sign-bit codewords and reports
+// beside each value the number of bits its codeword took.  The
+// lengths are counted bit by bit as DecodeStep consumes them, so they
+// add up, with the padding, to 8*len(compressed).  A codeword cut off
+// at the end gives io.ErrUnexpectedEOF along with the values before
+// it, and so does any other error from DecodeStep, such as
+// ErrOverflow.  A terminator ends the stream cleanly; the bits from
+// it on are not counted.
+func DecodeWithLengths(compressed []byte) (values []int, lengths []int, err error) {
+	values = make([]int, 0)
+	lengths = make([]int, 0)
+	var state DecodeState
+	nbits := 0
+	for _, b := range compressed {
+		for i := 7; i >= 0; i-- {
+			var emitted bool
+			var v int
+			state, emitted, v, err = DecodeStep(state, uint(b>>uint(i))&1)
+			if err == io.EOF {
+				return values, lengths, nil
+			} else if err != nil {
+				return values, lengths, err
+			}
+			nbits++
+			if emitted {
+				values = append(values, v)
+				lengths = append(lengths, nbits)
+				nbits = 0
+			}
+		}
+	}
+	if state.phase != COUNTING_ZEROS || state.zeros >= 8 {
+		err = io.ErrUnexpectedEOF
+	}
+	return values, lengths, err
+}
@@ -0,0 +1,242 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEntropy(t *testing.T) {
+	uniform := make([]int, 0)
+	for rep := 0; rep < 10; rep++ {
+		for v := -32; v < 32; v++ {
+			uniform = append(uniform, v)
+		}
+	}
+	if h := Entropy(uniform); math.Abs(h-6) > 1e-9 {
+		t.Errorf("Entropy of 64 equiprobable values = %f, want 6", h)
+	}
+
+	sampled := make([]int, 100000)
+	for i := range sampled {
+		sampled[i] = rand.Intn(16)
+	}
+	if h := Entropy(sampled); math.Abs(h-4) > 0.01 {
+		t.Errorf("Entropy of uniform samples over 16 values = %f, want about 4", h)
+	}
+
+	if h := Entropy([]int{7, 7, 7, 7}); h != 0 {
+		t.Errorf("Entropy of a single repeated value = %f, want 0", h)
+	}
+	if h := Entropy(nil); h != 0 {
+		t.Errorf("Entropy of nothing = %f, want 0", h)
+	}
+
+	// Exp-Golomb can never beat the entropy bound.
+	bpv := float64(EncodedLen(uniform)) / float64(len(uniform))
+	if bpv < Entropy(uniform) {
+		t.Errorf("Exp-Golomb used %f bits/value, below entropy %f", bpv, Entropy(uniform))
+	}
+}
+
+func TestEncodedLen(t *testing.T) {
+	vals := []int{0, 1, -1, 3, 1 << 40}
+	if l := EncodedLen(vals); l != 1+4+4+6+82 {
+		t.Fatalf("EncodedLen = %d, want %d", l, 1+4+4+6+82)
+	}
+	data := []int{4, 8, 3, 100, 100}
+	if l, b := EncodedLen(Deltas(0, data)), len(DeltaEncode(0, data)); (l+7)/8 != b {
+		t.Fatalf("EncodedLen %d bits does not round up to DeltaEncode's %d bytes", l, b)
+	}
+}
+
+func TestChooseBase(t *testing.T) {
+	data := []int{500, 510, 490, 505}
+	base := ChooseBase(data)
+	if base != 500 {
+		t.Fatalf("ChooseBase = %d, want 500", base)
+	}
+	best := len(DeltaEncode(base, data))
+	for _, other := range []int{0, 499, 501, -500} {
+		if n := len(DeltaEncode(other, data)); n < best {
+			t.Errorf("Start %d gave %d bytes, better than ChooseBase's %d", other, n, best)
+		}
+	}
+	if ChooseBase(nil) != 0 {
+		t.Error("ChooseBase of empty input should be 0")
+	}
+}
+
+func TestChooseOffset(t *testing.T) {
+	offsetLen := func(values []int, off int) int {
+		shifted := make([]int, len(values))
+		for i, v := range values {
+			shifted[i] = v - off
+		}
+		return EncodedLen(shifted)
+	}
+
+	// Centered:  values around zero need no offset.
+	centered := make([]int, 1001)
+	for i := range centered {
+		centered[i] = i - 500
+	}
+	rand.Shuffle(len(centered), func(i, j int) { centered[i], centered[j] = centered[j], centered[i] })
+	if off := ChooseOffset(centered); off != 0 {
+		t.Errorf("Centered data: offset %d, want 0", off)
+	}
+
+	// Skewed:  most values cluster at 10000, with a long tail.
+	skewed := make([]int, 0)
+	for i := 0; i < 900; i++ {
+		skewed = append(skewed, 10000+rand.Intn(21)-10)
+	}
+	for i := 0; i < 100; i++ {
+		skewed = append(skewed, rand.Intn(1000000))
+	}
+	off := ChooseOffset(skewed)
+	if off < 9990 || off > 10010 {
+		t.Errorf("Skewed data: offset %d, want about 10000", off)
+	}
+	mean := 0
+	for _, v := range skewed {
+		mean += v
+	}
+	mean /= len(skewed)
+	for _, other := range []int{0, mean} {
+		if offsetLen(skewed, off) >= offsetLen(skewed, other) {
+			t.Errorf("Skewed data: offset %d no better than %d", off, other)
+		}
+	}
+	if ChooseOffset(nil) != 0 {
+		t.Error("ChooseOffset of empty input should be 0")
+	}
+}
+
+func TestDecodeWithLengths(t *testing.T) {
+	// 1 | 0100 | 0111 | 001000 | 00010011 | pad 000
+	values, lengths, err := DecodeWithLengths([]byte{0xa3, 0x90, 0x26})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantValues := []int{0, 1, -2, 3, -8}
+	wantLengths := []int{1, 4, 4, 6, 8}
+	if len(values) != len(wantValues) || len(lengths) != len(wantLengths) {
+		t.Fatalf("Got %v %v", values, lengths)
+	}
+	for i := range wantValues {
+		if values[i] != wantValues[i] || lengths[i] != wantLengths[i] {
+			t.Fatalf("Item %d: got %d in %d bits, want %d in %d", i, values[i], lengths[i], wantValues[i], wantLengths[i])
+		}
+	}
+
+	data := make([]int, 2000)
+	for i := range data {
+		data[i] = rand.Int() >> uint(rand.Intn(64))
+	}
+	compressed := DeltaEncode(0, data)
+	values, lengths, err = DecodeWithLengths(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for i, l := range lengths {
+		if l != codeLen(values[i]) {
+			t.Fatalf("Item %d: %d bits, want %d", i, l, codeLen(values[i]))
+		}
+		total += l
+	}
+	if pad := 8*len(compressed) - total; pad < 0 || pad > 7 {
+		t.Fatalf("Lengths total %d bits of %d", total, 8*len(compressed))
+	}
+
+	if _, _, err := DecodeWithLengths([]byte{0x01}); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+}
+
+func TestCodewordParts(t *testing.T) {
+	parts := []struct {
+		v    int
+		want Codeword
+	}{
+		{0, Codeword{0, 1, 0, 0, -1}},
+		{1, Codeword{1, 1, 0, 1, 0}},
+		{-1, Codeword{1, 1, 0, 1, 1}},
+		{3, Codeword{2, 1, 0, 2, 0}},
+		{-7, Codeword{3, 1, 0, 3, 1}},
+		{1 << 40, Codeword{40, 1, 1, 40, 0}},
+		{-1 << 63, Codeword{63, 1, 1, 63, 1}},
+	}
+	for _, p := range parts {
+		c := CodewordParts(p.v)
+		if c != p.want {
+			t.Errorf("CodewordParts(%d) = %+v, want %+v", p.v, c, p.want)
+		}
+		if c.Len() != codeLen(p.v) {
+			t.Errorf("CodewordParts(%d).Len() = %d, codeLen %d", p.v, c.Len(), codeLen(p.v))
+		}
+	}
+
+	// Writing the parts out gives the encoder's bytes.
+	for i := 0; i < 1000; i++ {
+		v := rand.Int() >> uint(rand.Intn(64))
+		if i%2 == 1 {
+			v = -v
+		}
+		c := CodewordParts(v)
+		built, encoded := &bytes.Buffer{}, &bytes.Buffer{}
+		egs := NewExpGolombEncoder(built)
+		egs.WriteRawBits(0, uint(c.LeadingZeros))
+		egs.WriteRawBits(uint64(c.Prefix), 1)
+		egs.WriteRawBits(uint64(c.Suffix), uint(c.SuffixBits))
+		if c.Sign >= 0 {
+			egs.WriteRawBits(uint64(c.Sign), 1)
+		}
+		egs.Close()
+		egs = NewExpGolombEncoder(encoded)
+		egs.WriteInt(v)
+		egs.Close()
+		if !bytes.Equal(built.Bytes(), encoded.Bytes()) {
+			t.Fatalf("Parts of %d give %x, encoder %x", v, built.Bytes(), encoded.Bytes())
+		}
+	}
+}
+
+func TestMaxCodeLen(t *testing.T) {
+	ranges := [][2]int{{0, 0}, {-1, 1}, {-3, 2}, {5, 5}, {-1000, 10}, {-2, 70000}, {100, 200},
+		{-300, -100}, {-1 << 63, 1<<63 - 1}, {1<<62 - 5, 1 << 62}}
+	for _, r := range ranges {
+		want := 0
+		// Brute force over the range, or its ends and a sample of
+		// the middle for the large ones.
+		check := func(v int) {
+			if l := codeLen(v); l > want {
+				want = l
+			}
+		}
+		if r[1]-r[0] >= 0 && r[1]-r[0] < 1<<20 {
+			for v := r[0]; ; v++ {
+				check(v)
+				if v == r[1] {
+					break
+				}
+			}
+		} else {
+			check(r[0])
+			check(r[1])
+			check(0)
+		}
+		if got := MaxCodeLen(r[0], r[1]); got != want {
+			t.Errorf("MaxCodeLen(%d, %d) = %d, want %d", r[0], r[1], got, want)
+		}
+	}
+	if MaxCodeLen(1, 0) != 0 {
+		t.Error("Empty range should give 0")
+	}
+	if MaxCodeLen(-1<<63, 0) != 128 {
+		t.Errorf("MaxCodeLen of the most negative int = %d, want 128", MaxCodeLen(-1<<63, 0))
+	}
+}
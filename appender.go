@@ -0,0 +1,60 @@
+package deltagolomb
+
+import (
+	"bytes"
+)
+
+// An Appender extends a delta-coded stream produced by DeltaEncode
+// (or a previous Appender) without re-encoding what is already there.
+// The final byte of an encoded stream is usually only partly filled,
+// so the caller must keep the stream's length in bits and its last
+// value alongside the bytes; both are available from BitLen and Last
+// after Finish.
+type Appender struct {
+	buf   *bytes.Buffer
+	egs   *ExpGolombEncoder
+	last  int
+	nbits int
+}
+
+// NewAppender reopens encoded, whose first nbits bits hold codewords,
+// for appending.  last is the final value in the stream, or the
+// start value if the stream is empty.  encoded is not modified.
+func NewAppender(encoded []byte, nbits int, last int) *Appender {
+	full := nbits / 8
+	buf := &bytes.Buffer{}
+	buf.Write(encoded[:full])
+	a := &Appender{buf: buf, egs: NewExpGolombEncoder(buf), last: last}
+	if rem := uint(nbits % 8); rem > 0 {
+		a.egs.addBits(uint(encoded[full]>>(8-rem)), rem)
+	}
+	return a
+}
+
+// AppendValues delta-encodes vals onto the end of the stream.
+func (a *Appender) AppendValues(vals []int) {
+	for _, v := range vals {
+		a.egs.add(v - a.last)
+		a.last = v
+	}
+}
+
+// Finish pads and returns the extended stream.  The Appender must not
+// be used afterwards; reopen the result with NewAppender(result,
+// a.BitLen(), a.Last()) to append more.
+func (a *Appender) Finish() []byte {
+	a.nbits = 8*a.buf.Len() + int(egWordBits-a.egs.bitsleft)
+	a.egs.Close()
+	return a.buf.Bytes()
+}
+
+// BitLen returns the number of meaningful bits in the stream returned
+// by Finish, excluding padding.
+func (a *Appender) BitLen() int {
+	return a.nbits
+}
+
+// Last returns the last value appended to the stream.
+func (a *Appender) Last() int {
+	return a.last
+}
@@ -0,0 +1,42 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestAppender(t *testing.T) {
+	start := 40
+	data := make([]int, 500)
+	v := start
+	for i := range data {
+		v += rand.Intn(2001) - 1000
+		data[i] = v
+	}
+	want := DeltaEncode(start, data)
+
+	var encoded []byte
+	nbits, last := 0, start
+	for pos := 0; pos < len(data); {
+		end := pos + rand.Intn(9)
+		if end > len(data) {
+			end = len(data)
+		}
+		a := NewAppender(encoded, nbits, last)
+		a.AppendValues(data[pos:end])
+		encoded = a.Finish()
+		nbits, last = a.BitLen(), a.Last()
+		pos = end
+	}
+
+	if bytes.Compare(encoded, want) != 0 {
+		t.Fatalf("Incremental appends gave %v, want %v", encoded, want)
+	}
+	if last != data[len(data)-1] {
+		t.Fatalf("Last() = %d, want %d", last, data[len(data)-1])
+	}
+	if nbits > 8*len(encoded) || nbits <= 8*(len(encoded)-1) {
+		t.Fatalf("BitLen() = %d does not fit %d bytes", nbits, len(encoded))
+	}
+}
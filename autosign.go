@@ -0,0 +1,101 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+// Blocks are decoded into a buffer of the size given in the header,
+// so the header must not be trusted beyond this.
+const maxAutoBlockSize = 1 << 16
+
+var ErrBlockSize = errors.New("deltagolomb: block size in header exceeds 65536")
+
+// EncodeDeltaZigZagAuto delta-encodes data in blocks of blockSize
+// residuals, choosing for each block whichever of SignBit and ZigZag
+// codes it in fewer bits.  Each block starts with one flag bit, 0 for
+// SignBit and 1 for ZigZag, so a block never costs more than one bit
+// over the better of the two.  The block size is stored in a header;
+// sizes above 65536 are reduced to it.
+func EncodeDeltaZigZagAuto(w io.Writer, start int, blockSize int, data []int) error {
+	if blockSize < 1 {
+		blockSize = 1
+	}
+	if blockSize > maxAutoBlockSize {
+		blockSize = maxAutoBlockSize
+	}
+	egs := NewExpGolombEncoder(w)
+	egs.WriteUnsigned(uint(blockSize))
+
+	deltas := Deltas(start, data)
+	for len(deltas) > 0 {
+		block := deltas
+		if len(block) > blockSize {
+			block = block[:blockSize]
+		}
+		deltas = deltas[len(block):]
+
+		signBits, zigZagBits := 0, 0
+		for _, d := range block {
+			signBits += codeLen(d)
+			zigZagBits += unsignedCodeLen(zigZag(d))
+		}
+		egs.mode = SignBit
+		if zigZagBits < signBits {
+			egs.mode = ZigZag
+		}
+		egs.addBits(uint(egs.mode), 1)
+		egs.Write(block)
+	}
+	return egs.Close()
+}
+
+// DecodeDeltaZigZagAuto reverses EncodeDeltaZigZagAuto.  A header
+// giving a block size above 65536 returns ErrBlockSize.
+func DecodeDeltaZigZagAuto(r io.Reader, start int) ([]int, error) {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(r)
+	size := make([]uint, 1)
+	if n, err := decoder.ReadUnsigned(size); n == 0 {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return res, err
+	}
+	if size[0] > maxAutoBlockSize {
+		return res, ErrBlockSize
+	}
+
+	val := start
+	block := make([]int, size[0])
+	for {
+		flag, err := decoder.readBit()
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+		decoder.mode = SignMode(flag)
+		// Only the last block may be short; its padding decodes to
+		// nothing.
+		n, err := decoder.Read(block)
+		for _, d := range block[:n] {
+			val += d
+			res = append(res, val)
+		}
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+	}
+}
+
+// unsignedCodeLen returns the length in bits of u's unsigned
+// codeword.
+func unsignedCodeLen(u uint) int {
+	if u+1 == 0 {
+		return 2*egWordBits + 1
+	}
+	return 2*(bitLen(u+1)-1) + 1
+}
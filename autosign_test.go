@@ -0,0 +1,101 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestUnsignedCodeLen(t *testing.T) {
+	for _, u := range []uint{0, 1, 2, 3, 1000, 1 << 40, 1<<63 + 5, ^uint(0)} {
+		buf := &bytes.Buffer{}
+		e := NewExpGolombEncoder(buf)
+		e.addBits(0, 3)
+		e.WriteUnsigned(u)
+		e.Close()
+		bits := 8*buf.Len() - 3
+		if l := unsignedCodeLen(u); l > bits || l <= bits-8 {
+			t.Fatalf("unsignedCodeLen(%d) = %d, encoder wrote %d padded bits", u, l, bits)
+		}
+	}
+}
+
+func TestDeltaZigZagAuto(t *testing.T) {
+	// Residuals of +-1 are shorter in zig-zag, +-2 in sign-bit form.
+	start, blockSize := 10, 64
+	data := make([]int, 0)
+	v := start
+	for i := 0; i < blockSize; i++ {
+		v += 2*rand.Intn(2) - 1
+		data = append(data, v)
+	}
+	for i := 0; i < blockSize; i++ {
+		v += 4*rand.Intn(2) - 2
+		data = append(data, v)
+	}
+	for i := 0; i < 37; i++ {
+		v += rand.Intn(100) - 50
+		data = append(data, v)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := EncodeDeltaZigZagAuto(buf, start, blockSize, data); err != nil {
+		t.Fatal(err)
+	}
+	stream := buf.Bytes()
+
+	// Walk the first two block flags by hand.
+	d := NewExpGolombDecoder(bytes.NewReader(stream))
+	size := make([]uint, 1)
+	d.ReadUnsigned(size)
+	if size[0] != uint(blockSize) {
+		t.Fatalf("Header block size %d, want %d", size[0], blockSize)
+	}
+	skip := make([]int, blockSize)
+	for i, want := range []SignMode{ZigZag, SignBit} {
+		flag, _ := d.readBit()
+		if SignMode(flag) != want {
+			t.Fatalf("Block %d flag %d, want %d", i, flag, want)
+		}
+		d.mode = want
+		d.Read(skip)
+	}
+
+	res, err := DecodeDeltaZigZagAuto(bytes.NewReader(stream), start)
+	if err != nil {
+		t.Fatal("Decode failed: ", err)
+	}
+	if len(res) != len(data) {
+		t.Fatalf("Got %d values, want %d", len(res), len(data))
+	}
+	for i := range data {
+		if res[i] != data[i] {
+			t.Fatalf("item %d was %d, expected %d", i, res[i], data[i])
+		}
+	}
+}
+
+func TestDeltaZigZagAutoBlockSize(t *testing.T) {
+	for _, size := range []uint{1 << 62, maxAutoBlockSize + 1} {
+		buf := &bytes.Buffer{}
+		e := NewExpGolombEncoder(buf)
+		e.WriteUnsigned(size)
+		e.addBits(0, 1)
+		e.Write([]int{1, 2, 3})
+		e.Close()
+		if _, err := DecodeDeltaZigZagAuto(buf, 0); err != ErrBlockSize {
+			t.Fatalf("Block size %d: got %v, want ErrBlockSize", size, err)
+		}
+	}
+
+	// An oversized block size is reduced rather than written.
+	data := []int{1, 2, 3, 5, 8}
+	buf := &bytes.Buffer{}
+	if err := EncodeDeltaZigZagAuto(buf, 0, 1<<40, data); err != nil {
+		t.Fatal("EncodeDeltaZigZagAuto failed: ", err)
+	}
+	res, err := DecodeDeltaZigZagAuto(buf, 0)
+	if err != nil || len(res) != len(data) {
+		t.Fatalf("Got %v, %v, want %v", res, err, data)
+	}
+}
@@ -0,0 +1,51 @@
+package deltagolomb
+
+import (
+	"io"
+)
+
+// EncodeBiased codes values directly, with no delta stage, after
+// subtracting bias from each, so that values clustered in a band away
+// from zero get short codewords.  The bias leads the stream as a
+// signed codeword of its own.  ChooseOffset picks a good bias.
+func EncodeBiased(w io.Writer, bias int, values []int) error {
+	egs := NewExpGolombEncoder(w)
+	egs.WriteInt(bias)
+	for _, v := range values {
+		egs.WriteInt(v - bias)
+	}
+	return egs.Close()
+}
+
+// DecodeBiased reverses EncodeBiased, reading the bias from the
+// stream and adding it back to every value.  A stream with no bias,
+// or one that ends partway through a codeword, gives
+// io.ErrUnexpectedEOF along with the values decoded before it.
+func DecodeBiased(r io.Reader) ([]int, error) {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(r)
+	tmp := make([]int, 256)
+	n, err := decoder.Read(tmp[:1])
+	if n == 0 {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return res, err
+	}
+	bias := tmp[0]
+	for {
+		n, err := decoder.Read(tmp)
+		for _, v := range tmp[:n] {
+			res = append(res, v+bias)
+		}
+		if err == io.EOF && decoder.truncated() {
+			return res, io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+}
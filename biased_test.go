@@ -0,0 +1,52 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeBiased(t *testing.T) {
+	values := make([]int, 1000)
+	for i := range values {
+		values[i] = 1000 + rand.Intn(101)
+	}
+	values[0] = -1 << 63
+
+	buf := &bytes.Buffer{}
+	if err := EncodeBiased(buf, 1050, values); err != nil {
+		t.Fatal(err)
+	}
+	res, err := DecodeBiased(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(values) {
+		t.Fatalf("Got %d values, want %d", len(res), len(values))
+	}
+	for i := range values {
+		if res[i] != values[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], values[i])
+		}
+	}
+
+	// Codewords of about 12 bits instead of about 20.
+	values[0] = 1000
+	biased := &bytes.Buffer{}
+	EncodeBiased(biased, ChooseOffset(values), values)
+	raw := &bytes.Buffer{}
+	EncodeAbsolute(raw, values)
+	if biased.Len()*3 > raw.Len()*2 {
+		t.Fatalf("Biased coding took %d bytes, absolute %d", biased.Len(), raw.Len())
+	}
+
+	if res, err := DecodeBiased(bytes.NewReader(nil)); err != io.ErrUnexpectedEOF || res == nil {
+		t.Fatal("Expected io.ErrUnexpectedEOF for a missing bias, got ", res, err)
+	}
+	buf.Reset()
+	EncodeBiased(buf, 7, nil)
+	if res, err := DecodeBiased(buf); err != nil || len(res) != 0 {
+		t.Fatal("Empty stream gave ", res, err)
+	}
+}
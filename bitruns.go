@@ -0,0 +1,70 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrRunLength = errors.New("deltagolomb: run longer than 2^31")
+
+// EncodeBitRuns compresses a sequence of flags as the lengths of its
+// runs.  The first bit written is the value of the leading run; it is
+// followed by each run length minus one as an unsigned Exp-Golomb
+// codeword (runs are never empty).  An empty input produces no output.
+func EncodeBitRuns(w io.Writer, bits []bool) {
+	egs := NewExpGolombEncoder(w)
+	if len(bits) > 0 {
+		cur := bits[0]
+		if cur {
+			egs.addBits(1, 1)
+		} else {
+			egs.addBits(0, 1)
+		}
+		run := uint(0)
+		for _, b := range bits {
+			if b != cur {
+				egs.WriteUnsigned(run - 1)
+				cur = b
+				run = 0
+			}
+			run++
+		}
+		egs.WriteUnsigned(run - 1)
+	}
+	egs.Close()
+}
+
+// DecodeBitRuns reverses EncodeBitRuns, reading run lengths until r
+// is exhausted.  A run longer than 2^31 gives ErrRunLength along with
+// the flags before it.
+func DecodeBitRuns(r io.Reader) ([]bool, error) {
+	res := make([]bool, 0)
+	decoder := NewExpGolombDecoder(r)
+
+	bit, err := decoder.readBit()
+	if err == io.EOF {
+		return res, nil
+	} else if err != nil {
+		return res, err
+	}
+	cur := bit == 1
+
+	tmp := make([]uint, 1)
+	for {
+		n, err := decoder.ReadUnsigned(tmp)
+		if n > 0 {
+			if tmp[0] >= 1<<31 {
+				return res, ErrRunLength
+			}
+			for i := uint(0); i <= tmp[0]; i++ {
+				res = append(res, cur)
+			}
+			cur = !cur
+		}
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+	}
+}
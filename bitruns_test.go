@@ -0,0 +1,67 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func checkBitRuns(t *testing.T, name string, bits []bool) int {
+	buf := &bytes.Buffer{}
+	EncodeBitRuns(buf, bits)
+	size := buf.Len()
+	res, err := DecodeBitRuns(buf)
+	if err != nil {
+		t.Fatalf("%s: DecodeBitRuns failed: %v", name, err)
+	}
+	if len(res) != len(bits) {
+		t.Fatalf("%s: got %d bits, want %d", name, len(res), len(bits))
+	}
+	for i := range bits {
+		if res[i] != bits[i] {
+			t.Fatalf("%s: bit %d was %v, want %v", name, i, res[i], bits[i])
+		}
+	}
+	return size
+}
+
+func TestBitRuns(t *testing.T) {
+	allTrue := make([]bool, 1000)
+	for i := range allTrue {
+		allTrue[i] = true
+	}
+	if size := checkBitRuns(t, "all-true", allTrue); size > 3 {
+		t.Errorf("all-true: encoded to %d bytes, expected at most 3", size)
+	}
+
+	alternating := make([]bool, 101)
+	for i := range alternating {
+		alternating[i] = i%2 == 1
+	}
+	checkBitRuns(t, "alternating", alternating)
+
+	longRuns := make([]bool, 0)
+	for i, n := range []int{5000, 1, 70000, 3, 129} {
+		for j := 0; j < n; j++ {
+			longRuns = append(longRuns, i%2 == 0)
+		}
+	}
+	checkBitRuns(t, "long-runs", longRuns)
+
+	checkBitRuns(t, "single", []bool{false})
+	checkBitRuns(t, "empty", []bool{})
+}
+
+func TestBitRunsTooLong(t *testing.T) {
+	for _, run := range []uint{1 << 31, ^uint(0)} {
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoder(buf)
+		egs.addBits(1, 1)
+		egs.WriteUnsigned(2)
+		egs.WriteUnsigned(run)
+		egs.Close()
+		res, err := DecodeBitRuns(buf)
+		if err != ErrRunLength || len(res) != 3 {
+			t.Fatalf("Run of %d gave %d flags, %v", run, len(res), err)
+		}
+	}
+}
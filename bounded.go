@@ -0,0 +1,137 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrBound           = errors.New("deltagolomb: bound needs maxLen >= 2 and 1 <= rawBits <= 64")
+	ErrValueTooWide    = errors.New("deltagolomb: value does not fit in rawBits signed bits")
+	ErrCodewordTooLong = errors.New("deltagolomb: codeword prefix longer than the escape")
+)
+
+// EncodeBounded Exp-Golomb codes values so that no codeword is longer
+// than maxLen bits, for decoders with a fixed-size shift register.
+// A value whose codeword would be longer is replaced by an escape:
+// the zero prefix one longer than any ordinary codeword allowed under
+// maxLen, a one, and then the value as rawBits-bit two's complement.
+// Every codeword is therefore at most max(maxLen, escape+rawBits)
+// bits, which never exceeds maxLen+rawBits.  Values that need an
+// escape must fit in rawBits signed bits.
+func EncodeBounded(w io.Writer, maxLen, rawBits uint, values []int) error {
+	escape, err := boundedEscape(maxLen, rawBits)
+	if err != nil {
+		return err
+	}
+	egs := NewExpGolombEncoder(w)
+	for _, v := range values {
+		if codeLen(v) <= int(maxLen) {
+			egs.WriteInt(v)
+			continue
+		}
+		if rawBits < 64 && (v < -1<<(rawBits-1) || v >= 1<<(rawBits-1)) {
+			egs.Close()
+			return ErrValueTooWide
+		}
+		egs.addZeroBits(escape)
+		egs.addBits(1, 1)
+		egs.addBits(uint(v)&(1<<rawBits-1), rawBits)
+	}
+	return egs.Close()
+}
+
+// DecodeBounded reverses EncodeBounded; maxLen and rawBits must match.
+func DecodeBounded(r io.Reader, maxLen, rawBits uint) ([]int, error) {
+	return decodeBounded(r, maxLen, rawBits, nil)
+}
+
+// decodeBounded does the work of DecodeBounded.  If lens is not nil
+// the length in bits of each codeword is appended to it.
+func decodeBounded(r io.Reader, maxLen, rawBits uint, lens *[]int) ([]int, error) {
+	res := make([]int, 0)
+	escape, err := boundedEscape(maxLen, rawBits)
+	if err != nil {
+		return res, err
+	}
+	decoder := NewExpGolombDecoder(r)
+	// Reads n bits, reporting any shortfall as truncation.
+	read := func(n uint) (uint, error) {
+		v := uint(0)
+		for i := uint(0); i < n; i++ {
+			bit, err := decoder.readBit()
+			if err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return 0, err
+			}
+			v = v<<1 | bit
+		}
+		return v, nil
+	}
+
+	for {
+		zeros := uint(0)
+		for {
+			bit, err := decoder.readBit()
+			if err == io.EOF && zeros < 8 {
+				return res, nil // padding
+			} else if err == io.EOF {
+				return res, io.ErrUnexpectedEOF
+			} else if err != nil {
+				return res, err
+			}
+			if bit == 1 {
+				break
+			}
+			zeros++
+			// Up to seven zeros past the escape may still be padding.
+			if zeros > escape && zeros >= 8 {
+				return res, ErrCodewordTooLong
+			}
+		}
+		if zeros > escape {
+			return res, ErrCodewordTooLong
+		}
+
+		var v int
+		var n uint
+		switch {
+		case zeros == 0:
+			v, n = 0, 1
+		case zeros == escape:
+			raw, err := read(rawBits)
+			if err != nil {
+				return res, err
+			}
+			// Sign-extend from rawBits.
+			v = int(raw<<(64-rawBits)) >> (64 - rawBits)
+			n = zeros + 1 + rawBits
+		default:
+			mag, err := read(zeros + 1)
+			if err != nil {
+				return res, err
+			}
+			v = int(mag>>1 | 1<<zeros - 1)
+			if mag&1 == 1 {
+				v = -v
+			}
+			n = 2*zeros + 2
+		}
+		res = append(res, v)
+		if lens != nil {
+			*lens = append(*lens, int(n))
+		}
+	}
+}
+
+// boundedEscape returns the length of the escape prefix for maxLen:
+// one zero more than the longest prefix whose signed codeword,
+// 2*zeros+2 bits, fits in maxLen.
+func boundedEscape(maxLen, rawBits uint) (uint, error) {
+	if maxLen < 2 || rawBits < 1 || rawBits > 64 {
+		return 0, ErrBound
+	}
+	return (maxLen-2)/2 + 1, nil
+}
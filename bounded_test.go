@@ -0,0 +1,61 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeBounded(t *testing.T) {
+	values := make([]int, 0)
+	for i := 0; i < 2000; i++ {
+		values = append(values, rand.Intn(41)-20)
+	}
+	values = append(values, 1<<20, -(1 << 20), 1<<31-1, -1<<31, 1000, 0)
+	rand.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+
+	for _, bound := range [][2]uint{{2, 32}, {3, 40}, {10, 32}, {17, 33}, {64, 64}} {
+		maxLen, rawBits := bound[0], bound[1]
+		buf := &bytes.Buffer{}
+		if err := EncodeBounded(buf, maxLen, rawBits, values); err != nil {
+			t.Fatalf("Bound %v: %v", bound, err)
+		}
+		lens := make([]int, 0)
+		res, err := decodeBounded(buf, maxLen, rawBits, &lens)
+		if err != nil {
+			t.Fatalf("Bound %v: decode failed: %v", bound, err)
+		}
+		if len(res) != len(values) {
+			t.Fatalf("Bound %v: got %d values, want %d", bound, len(res), len(values))
+		}
+		escapes := 0
+		for i := range values {
+			if res[i] != values[i] {
+				t.Fatalf("Bound %v: item %d was %d, expected %d", bound, i, res[i], values[i])
+			}
+			if lens[i] > int(maxLen+rawBits) {
+				t.Fatalf("Bound %v: item %d used %d bits", bound, i, lens[i])
+			}
+			if lens[i] > int(maxLen) {
+				escapes++
+			}
+		}
+		if maxLen < 40 && escapes == 0 {
+			t.Errorf("Bound %v: expected some escapes", bound)
+		}
+	}
+}
+
+func TestEncodeBoundedErrors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := EncodeBounded(buf, 1, 8, nil); err != ErrBound {
+		t.Fatal("Expected ErrBound, got ", err)
+	}
+	if err := EncodeBounded(buf, 6, 8, []int{127, 128}); err != ErrValueTooWide {
+		t.Fatal("Expected ErrValueTooWide, got ", err)
+	}
+	// maxLen 4 escapes after two zeros; three zeros is never valid.
+	if _, err := DecodeBounded(bytes.NewBuffer([]byte{0x10}), 4, 8); err != ErrCodewordTooLong {
+		t.Fatal("Expected ErrCodewordTooLong, got ", err)
+	}
+}
@@ -0,0 +1,51 @@
+package deltagolomb
+
+import (
+	"io"
+)
+
+// A BufferedDecoder pulls delta-coded values from a stream one at a
+// time, decoding at most capacity values ahead of the caller.  Memory
+// use is fixed no matter how long the stream is.
+type BufferedDecoder struct {
+	decoder *ExpGolombDecoder
+	val     int
+	buf     []int
+	pos     int
+	n       int
+	err     error
+}
+
+// Create a new BufferedDecoder reading residuals from r and adding
+// them to base.  A capacity below one is treated as one.
+func NewBufferedDecoder(r io.Reader, base int, capacity int) *BufferedDecoder {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &BufferedDecoder{
+		decoder: NewExpGolombDecoder(r),
+		val:     base,
+		buf:     make([]int, capacity),
+	}
+}
+
+// Next returns the next absolute value.  ok is false once the stream
+// is exhausted; err is nil if it ended cleanly at EOF.
+func (b *BufferedDecoder) Next() (value int, ok bool, err error) {
+	if b.pos == b.n {
+		if b.err != nil {
+			if b.err == io.EOF {
+				return 0, false, nil
+			}
+			return 0, false, b.err
+		}
+		b.n, b.err = b.decoder.Read(b.buf)
+		b.pos = 0
+		if b.n == 0 {
+			return b.Next()
+		}
+	}
+	b.val += b.buf[b.pos]
+	b.pos++
+	return b.val, true, nil
+}
@@ -0,0 +1,46 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestBufferedDecoder(t *testing.T) {
+	data := make([]int, 100000)
+	v := 0
+	for i := range data {
+		v += rand.Intn(201) - 100
+		data[i] = v
+	}
+	base := 17
+	compressed := DeltaEncode(base, data)
+	want := DeltaDecode(base, compressed)
+
+	for _, capacity := range []int{0, 1, 7, 256} {
+		bd := NewBufferedDecoder(bytes.NewBuffer(compressed), base, capacity)
+		if capacity > 0 && len(bd.buf) != capacity {
+			t.Fatalf("Capacity %d: buffer holds %d values", capacity, len(bd.buf))
+		}
+		i := 0
+		for {
+			val, ok, err := bd.Next()
+			if err != nil {
+				t.Fatalf("Capacity %d: %v", capacity, err)
+			}
+			if !ok {
+				break
+			}
+			if i >= len(want) || val != want[i] {
+				t.Fatalf("Capacity %d: item %d was %d", capacity, i, val)
+			}
+			i++
+		}
+		if i != len(want) {
+			t.Fatalf("Capacity %d: got %d values, want %d", capacity, i, len(want))
+		}
+		if cap(bd.buf) > capacity && cap(bd.buf) > 1 {
+			t.Fatalf("Capacity %d: buffer grew to %d", capacity, cap(bd.buf))
+		}
+	}
+}
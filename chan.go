@@ -0,0 +1,28 @@
+package deltagolomb
+
+import (
+	"io"
+)
+
+// EncodeChan delta-encodes the values received from ch, starting from
+// start, until ch is closed, then closes the encoder.  The stream is
+// the one DeltaEncode would write for the same values.  If w fails,
+// EncodeChan returns the error at once; a goroutine carries on
+// receiving from ch and discards what it gets, so that the producer
+// is not left blocked, until ch is closed.
+func EncodeChan(w io.Writer, start int, ch <-chan int) error {
+	egs := NewExpGolombEncoder(w)
+	prev := start
+	for v := range ch {
+		egs.WriteInt(v - prev)
+		prev = v
+		if egs.err != nil {
+			go func() {
+				for range ch {
+				}
+			}()
+			return egs.err
+		}
+	}
+	return egs.Close()
+}
@@ -0,0 +1,53 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeChan(t *testing.T) {
+	data := make([]int, 5000)
+	for i := range data {
+		data[i] = rand.Intn(2001) - 1000
+	}
+	ch := make(chan int)
+	go func() {
+		for _, v := range data {
+			ch <- v
+		}
+		close(ch)
+	}()
+
+	buf := &bytes.Buffer{}
+	if err := EncodeChan(buf, 4, ch); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), DeltaEncode(4, data)) {
+		t.Fatal("EncodeChan differs from DeltaEncode")
+	}
+	res := DeltaDecode(4, buf.Bytes())
+	if len(res) != len(data) {
+		t.Fatalf("Got %d values, want %d", len(res), len(data))
+	}
+	for i := range data {
+		if res[i] != data[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], data[i])
+		}
+	}
+
+	// The producer must be able to finish after a write failure.
+	ch = make(chan int)
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100000; i++ {
+			ch <- i * i
+		}
+		close(ch)
+		done <- true
+	}()
+	if err := EncodeChan(&failWriter{limit: 16}, 0, ch); err != errFailWriter {
+		t.Fatal("Expected the write failure, got ", err)
+	}
+	<-done
+}
@@ -0,0 +1,55 @@
+package deltagolomb
+
+import (
+	"errors"
+	"strconv"
+)
+
+var ErrWidth = errors.New("deltagolomb: width must be between 1 and 64 bits")
+
+// WidthError reports a value or delta that does not fit the width
+// given to DeltaEncodeChecked.
+type WidthError struct {
+	Index int  // position in data
+	Value int  // the offending value or delta
+	Delta bool // whether Value is the delta into data[Index]
+	Bits  uint // the width checked against
+}
+
+func (e *WidthError) Error() string {
+	what := "value "
+	if e.Delta {
+		what = "delta "
+	}
+	return "deltagolomb: " + what + strconv.Itoa(e.Value) + " at index " +
+		strconv.Itoa(e.Index) + " does not fit in " +
+		strconv.FormatUint(uint64(e.Bits), 10) + " signed bits"
+}
+
+// DeltaEncodeChecked is DeltaEncode for data meant to fit a narrower
+// integer type:  it first checks that every value, and every delta
+// including the first one from start, fits in bits signed bits.  The
+// first failure is returned as a *WidthError and nothing is encoded.
+// At 64 bits this catches deltas that overflow int itself.
+func DeltaEncodeChecked(start int, data []int, bits uint) ([]byte, error) {
+	if bits < 1 || bits > 64 {
+		return nil, ErrWidth
+	}
+	fits := func(x int) bool {
+		return bits >= 64 || (x >= -1<<(bits-1) && x < 1<<(bits-1))
+	}
+	prev := start
+	for i, v := range data {
+		if !fits(v) {
+			return nil, &WidthError{Index: i, Value: v, Bits: bits}
+		}
+		d := v - prev
+		// The subtraction overflowed if the operands' signs differ
+		// and the result's sign differs from v's.
+		if !fits(d) || (v^prev)&(v^d) < 0 {
+			return nil, &WidthError{Index: i, Value: d, Delta: true, Bits: bits}
+		}
+		prev = v
+	}
+	return DeltaEncode(start, data), nil
+}
@@ -0,0 +1,48 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestDeltaEncodeChecked(t *testing.T) {
+	data := []int{100, -100, math.MaxInt16, math.MinInt16, 0}
+	encoded, err := DeltaEncodeChecked(0, data, 17)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, DeltaEncode(0, data)) {
+		t.Fatal("Checked encoding differs from DeltaEncode")
+	}
+
+	cases := []struct {
+		start int
+		data  []int
+		bits  uint
+		index int
+		delta bool
+	}{
+		{0, []int{1, 2, math.MaxInt16 + 1}, 16, 2, false},
+		{0, []int{5, math.MinInt16 - 1}, 16, 1, false},
+		{0, []int{math.MaxInt16, math.MinInt16}, 16, 1, true},
+		{math.MinInt32, []int{math.MaxInt32}, 32, 0, true},
+		{0, []int{math.MaxInt64, math.MinInt64}, 64, 1, true},
+		{math.MinInt64, []int{1}, 64, 0, true},
+	}
+	for _, c := range cases {
+		_, err := DeltaEncodeChecked(c.start, c.data, c.bits)
+		werr, ok := err.(*WidthError)
+		if !ok || werr.Index != c.index || werr.Delta != c.delta || werr.Bits != c.bits {
+			t.Fatalf("Data %v at %d bits: got %v", c.data, c.bits, err)
+		}
+	}
+
+	if _, err := DeltaEncodeChecked(0, data, 0); err != ErrWidth {
+		t.Fatal("Expected ErrWidth, got ", err)
+	}
+	want := "deltagolomb: delta -65535 at index 1 does not fit in 16 signed bits"
+	if _, err := DeltaEncodeChecked(0, []int{math.MaxInt16, math.MinInt16}, 16); err.Error() != want {
+		t.Fatalf("Got message %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,83 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrClampLimit = errors.New("deltagolomb: clamp limit must be non-negative")
+
+// EncodeClamped delta-encodes data like DeltaEncode, but bounds the
+// length of every codeword.  Residuals whose magnitude exceeds limit
+// are not coded directly; instead the encoder emits an escape, the
+// codeword for limit+1 (which no ordinary residual can use), followed
+// by the absolute value as 64 raw bits.  Prediction then continues
+// from that value.  The limit is stored as a header codeword.
+func EncodeClamped(w io.Writer, start int, limit int, data []int) error {
+	if limit < 0 || limit == int(^uint(0)>>1) {
+		return ErrClampLimit
+	}
+	egs := NewExpGolombEncoder(w)
+	egs.WriteUnsigned(uint(limit))
+	prev := start
+	for _, v := range data {
+		// uint(-r) is the true magnitude even for the most negative int.
+		r := v - prev
+		mag := uint(r)
+		if r < 0 {
+			mag = uint(-r)
+		}
+		if mag > uint(limit) {
+			egs.WriteInt(limit + 1)
+			egs.addBits(uint(v), 64)
+		} else {
+			egs.WriteInt(r)
+		}
+		prev = v
+	}
+	return egs.Close()
+}
+
+// DecodeClamped reverses EncodeClamped.
+func DecodeClamped(r io.Reader, start int) ([]int, error) {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(r)
+	ltmp := make([]uint, 1)
+	if n, err := decoder.ReadUnsigned(ltmp); n == 0 {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return res, err
+	}
+	escape := int(ltmp[0]) + 1
+
+	val := start
+	tmp := make([]int, 1)
+	for {
+		n, err := decoder.Read(tmp)
+		if n > 0 {
+			if tmp[0] == escape {
+				raw := uint(0)
+				for i := 0; i < 64; i++ {
+					bit, rerr := decoder.readBit()
+					if rerr != nil {
+						if rerr == io.EOF {
+							rerr = io.ErrUnexpectedEOF
+						}
+						return res, rerr
+					}
+					raw = raw<<1 | bit
+				}
+				val = int(raw)
+			} else {
+				val += tmp[0]
+			}
+			res = append(res, val)
+		}
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+	}
+}
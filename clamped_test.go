@@ -0,0 +1,57 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeClamped(t *testing.T) {
+	smooth := make([]int, 5000)
+	v := 1000
+	for i := range smooth {
+		v += rand.Intn(9) - 4
+		smooth[i] = v
+	}
+	spiky := make([]int, len(smooth))
+	copy(spiky, smooth)
+	spikes := []int{10, 2000, 2001, 4999}
+	for _, i := range spikes {
+		spiky[i] = 1 << 40
+	}
+	spiky[3000] = -1 << 63
+
+	start, limit := 1000, 16
+	buf := &bytes.Buffer{}
+	if err := EncodeClamped(buf, start, limit, spiky); err != nil {
+		t.Fatal(err)
+	}
+	size := buf.Len()
+	res, err := DecodeClamped(buf, start)
+	if err != nil {
+		t.Fatal("DecodeClamped failed: ", err)
+	}
+	if len(res) != len(spiky) {
+		t.Fatalf("Got %d values, want %d", len(res), len(spiky))
+	}
+	for i := range spiky {
+		if res[i] != spiky[i] {
+			t.Fatalf("item %d was %d, expected %d", i, res[i], spiky[i])
+		}
+	}
+
+	// Normal residuals cost what plain delta coding costs; each spike
+	// adds at most two escapes (into and out of the spike).
+	escape := codeLen(limit+1) + 64
+	bound := len(DeltaEncode(start, smooth)) + (2*(len(spikes)+1)*escape+codeLen(limit))/8 + 2
+	if size > bound {
+		t.Errorf("Clamped stream is %d bytes, expected at most %d", size, bound)
+	}
+
+	if err := EncodeClamped(buf, start, -1, spiky); err != ErrClampLimit {
+		t.Fatal("Expected ErrClampLimit, got ", err)
+	}
+	if err := EncodeClamped(&failWriter{}, start, 8, spiky); err != errFailWriter {
+		t.Fatal("Expected the writer's error, got ", err)
+	}
+}
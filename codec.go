@@ -0,0 +1,105 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+var (
+	ErrCodecID      = errors.New("deltagolomb: codec ID already registered")
+	ErrUnknownCodec = errors.New("deltagolomb: no codec registered for ID")
+)
+
+// Codec is a pair of functions that write and read a whole []int.
+// Decode is handed a reader over exactly the bytes Encode wrote.
+type Codec struct {
+	Encode func(w io.Writer, values []int) error
+	Decode func(r io.Reader) ([]int, error)
+}
+
+// IDs of the codecs registered by the package.  IDs below 16 are
+// reserved for it.
+const (
+	CodecSelfBased byte = 0 // WriteBlockSelfBased
+	CodecPlanar    byte = 1 // EncodePlanar
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[byte]Codec{
+		CodecSelfBased: {WriteBlockSelfBased, DecodeBlockSelfBased},
+		CodecPlanar:    {EncodePlanar, DecodePlanar},
+	}
+)
+
+// RegisterCodec makes c available to WriteBlock and ReadBlock under
+// id.  An id already in use gives ErrCodecID.
+func RegisterCodec(id byte, c Codec) error {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, ok := codecs[id]; ok {
+		return ErrCodecID
+	}
+	codecs[id] = c
+	return nil
+}
+
+func lookupCodec(id byte) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[id]
+	if !ok {
+		return Codec{}, ErrUnknownCodec
+	}
+	return c, nil
+}
+
+// WriteBlock codes values with the codec registered under id and
+// writes a self-describing block:  the ID byte, the length of the
+// payload as an unsigned varint, then the payload.  Blocks can be
+// written back to back.
+func WriteBlock(w io.Writer, id byte, values []int) error {
+	c, err := lookupCodec(id)
+	if err != nil {
+		return err
+	}
+	payload := &bytes.Buffer{}
+	if err := c.Encode(payload, values); err != nil {
+		return err
+	}
+	var hdr [1 + binary.MaxVarintLen64]byte
+	hdr[0] = id
+	n := 1 + binary.PutUvarint(hdr[1:], uint64(payload.Len()))
+	if _, err := w.Write(hdr[:n]); err != nil {
+		return err
+	}
+	_, err = w.Write(payload.Bytes())
+	return err
+}
+
+// ReadBlock reads one block written by WriteBlock and decodes it with
+// the codec its ID names.  At the end of the stream it returns
+// io.EOF; a block cut short gives io.ErrUnexpectedEOF, and one whose
+// length is above 2^31 gives ErrFrameLength.  To read
+// consecutive blocks r must be an io.ByteReader, such as a
+// *bytes.Reader or *bufio.Reader, or it may be read past the block.
+func ReadBlock(r io.Reader) ([]int, error) {
+	br := makeReader(r)
+	id, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	c, err := lookupCodec(id)
+	if err != nil {
+		return nil, err
+	}
+	// The length and payload are laid out as EncodeFramed's frames.
+	payload, err := readFrame(br)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(bytes.NewReader(payload))
+}
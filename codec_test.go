@@ -0,0 +1,76 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCodecBlocks(t *testing.T) {
+	series := [][]int{{5, 9, 2, -40, 1 << 40}, {0, 0, 3, -3}, {7, 8, 9}}
+	// Values coded directly, a third codec for the registry.
+	err := RegisterCodec(16, Codec{
+		func(w io.Writer, values []int) error {
+			EncodeAbsolute(w, values)
+			return nil
+		},
+		func(r io.Reader) ([]int, error) {
+			compressed, err := ioutil.ReadAll(r)
+			return DecodeAbsolute(compressed), err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		codecsMu.Lock()
+		delete(codecs, 16)
+		codecsMu.Unlock()
+	}()
+	if err := RegisterCodec(CodecPlanar, Codec{}); err != ErrCodecID {
+		t.Fatal("Expected ErrCodecID, got ", err)
+	}
+
+	buf := &bytes.Buffer{}
+	for i, id := range []byte{CodecSelfBased, CodecPlanar, 16} {
+		if err := WriteBlock(buf, id, series[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream := buf.Bytes()
+
+	r := bytes.NewReader(stream)
+	for i, want := range series {
+		got, err := ReadBlock(r)
+		if err != nil {
+			t.Fatalf("Block %d: %v", i, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("Block %d: got %d values, want %d", i, len(got), len(want))
+		}
+		for j := range want {
+			if got[j] != want[j] {
+				t.Fatalf("Block %d item %d was %d, expected %d", i, j, got[j], want[j])
+			}
+		}
+	}
+	if _, err := ReadBlock(r); err != io.EOF {
+		t.Fatal("Expected io.EOF after the last block, got ", err)
+	}
+
+	if _, err := ReadBlock(bytes.NewReader(stream[:5])); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+	// A payload length of 2^63.
+	huge := []byte{CodecPlanar, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}
+	if _, err := ReadBlock(bytes.NewReader(huge)); err != ErrFrameLength {
+		t.Fatal("Expected ErrFrameLength, got ", err)
+	}
+	if err := WriteBlock(buf, 200, nil); err != ErrUnknownCodec {
+		t.Fatal("Expected ErrUnknownCodec, got ", err)
+	}
+	if _, err := ReadBlock(bytes.NewReader([]byte{200, 0})); err != ErrUnknownCodec {
+		t.Fatal("Expected ErrUnknownCodec, got ", err)
+	}
+}
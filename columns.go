@@ -0,0 +1,113 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrColumnCount  = errors.New("deltagolomb: column count does not match number of starts")
+	ErrColumnLength = errors.New("deltagolomb: negative column length")
+)
+
+// EncodeColumns delta-encodes several parallel series into a single
+// Exp-Golomb stream, interleaving the residuals row by row so that
+// samples taken at the same time stay close together.
+//
+// The stream starts with a small header: the number of columns
+// followed by the length of each column.  Columns may be ragged;
+// once a column runs out it is simply skipped in the remaining rows.
+// Each column i is delta-coded independently against starts[i].
+func EncodeColumns(w io.Writer, starts []int, columns [][]int) error {
+	if len(starts) != len(columns) {
+		return ErrColumnCount
+	}
+	egs := NewExpGolombEncoder(w)
+	egs.WriteInt(len(columns))
+	rows := 0
+	for _, col := range columns {
+		egs.WriteInt(len(col))
+		if len(col) > rows {
+			rows = len(col)
+		}
+	}
+
+	prev := make([]int, len(starts))
+	copy(prev, starts)
+	for r := 0; r < rows; r++ {
+		for c, col := range columns {
+			if r >= len(col) {
+				continue
+			}
+			egs.WriteInt(col[r] - prev[c])
+			prev[c] = col[r]
+		}
+	}
+	return egs.Close()
+}
+
+// DecodeColumns reverses EncodeColumns.  The caller must supply the
+// same starts that were used to encode; the number of columns stored
+// in the header must match len(starts).  A stream that ends before
+// every column has been filled returns io.ErrUnexpectedEOF, however
+// long the header claims the columns are.
+func DecodeColumns(r io.Reader, starts []int) ([][]int, error) {
+	decoder := NewExpGolombDecoder(r)
+	tmp := make([]int, 1)
+	next := func() (int, error) {
+		// Read may deliver the final value together with io.EOF.
+		if n, err := decoder.Read(tmp); n == 0 {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		return tmp[0], nil
+	}
+
+	ncols, err := next()
+	if err != nil {
+		return nil, err
+	}
+	if ncols != len(starts) {
+		return nil, ErrColumnCount
+	}
+
+	columns := make([][]int, ncols)
+	lens := make([]int, ncols)
+	rows := 0
+	for c := range columns {
+		n, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, ErrColumnLength
+		}
+		lens[c] = n
+		if n > maxPrealloc {
+			n = maxPrealloc
+		}
+		columns[c] = make([]int, 0, n)
+		if lens[c] > rows {
+			rows = lens[c]
+		}
+	}
+
+	prev := make([]int, ncols)
+	copy(prev, starts)
+	for r := 0; r < rows; r++ {
+		for c := range columns {
+			if r >= lens[c] {
+				continue
+			}
+			delta, err := next()
+			if err != nil {
+				return nil, err
+			}
+			prev[c] += delta
+			columns[c] = append(columns[c], prev[c])
+		}
+	}
+	return columns, nil
+}
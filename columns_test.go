@@ -0,0 +1,71 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestEncodeDecodeColumns(t *testing.T) {
+	starts := []int{100, -5, 0}
+	columns := [][]int{
+		{101, 103, 103, 99, 120, 121},
+		{-5, -6},
+		{7, 14, 21, 28},
+	}
+	buf := &bytes.Buffer{}
+	if err := EncodeColumns(buf, starts, columns); err != nil {
+		t.Fatal("EncodeColumns failed: ", err)
+	}
+	res, err := DecodeColumns(buf, starts)
+	if err != nil {
+		t.Fatal("DecodeColumns failed: ", err)
+	}
+	if len(res) != len(columns) {
+		t.Fatalf("Got %d columns, want %d", len(res), len(columns))
+	}
+	for c := range columns {
+		if len(res[c]) != len(columns[c]) {
+			t.Fatalf("Column %d has %d values, want %d", c, len(res[c]), len(columns[c]))
+		}
+		for i := range columns[c] {
+			if res[c][i] != columns[c][i] {
+				t.Fatalf("Column %d item %d was %d, want %d", c, i, res[c][i], columns[c][i])
+			}
+		}
+	}
+}
+
+func TestColumnsErrors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := EncodeColumns(buf, []int{0}, [][]int{{1}, {2}}); err != ErrColumnCount {
+		t.Fatal("Expected ErrColumnCount on encode, got ", err)
+	}
+	EncodeColumns(buf, []int{0, 0}, [][]int{{1}, {2}})
+	if _, err := DecodeColumns(bytes.NewBuffer(buf.Bytes()), []int{0}); err != ErrColumnCount {
+		t.Fatal("Expected ErrColumnCount on decode, got ", err)
+	}
+	// Drop the residuals, keeping only part of the header.
+	if _, err := DecodeColumns(bytes.NewBuffer(buf.Bytes()[:1]), []int{0, 0}); err == nil {
+		t.Fatal("Expected an error decoding a truncated stream")
+	}
+	if err := EncodeColumns(&failWriter{}, []int{0}, [][]int{{1}}); err != errFailWriter {
+		t.Fatal("Expected the writer's error, got ", err)
+	}
+}
+
+func TestColumnsBadLength(t *testing.T) {
+	for _, n := range []int{1 << 62, -1} {
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoder(buf)
+		egs.Write([]int{1, n, 5})
+		egs.Close()
+		_, err := DecodeColumns(buf, []int{0})
+		if n < 0 && err != ErrColumnLength {
+			t.Fatal("Expected ErrColumnLength for length ", n, ", got ", err)
+		}
+		if n > 0 && err != io.ErrUnexpectedEOF {
+			t.Fatal("Expected io.ErrUnexpectedEOF for length ", n, ", got ", err)
+		}
+	}
+}
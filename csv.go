@@ -0,0 +1,74 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// csvReader formats the values of a delta-coded stream a chunk at a
+// time, holding any text Read had no room for until the next call.
+type csvReader struct {
+	d       *ExpGolombDecoder
+	val     int
+	tmp     []int
+	pending []byte
+	first   bool
+	err     error // returned once pending is drained
+}
+
+// DecodeCSVReader returns a reader of the values of a stream written
+// by DeltaEncode as comma-separated decimal text ending in a newline,
+// for inspection with text tools.  Values are decoded as Read asks
+// for text, not all at once.  A stream that ends partway through a
+// codeword gives io.ErrUnexpectedEOF, without the newline, after the
+// text of the values before it.
+func DecodeCSVReader(base int, compressed []byte) io.Reader {
+	return &csvReader{
+		d:     NewExpGolombDecoder(bytes.NewReader(compressed)),
+		val:   base,
+		tmp:   make([]int, 64),
+		first: true,
+	}
+}
+
+func (s *csvReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(s.pending) == 0 {
+			if s.err != nil {
+				return n, s.err
+			}
+			s.fill()
+			continue
+		}
+		c := copy(p[n:], s.pending)
+		s.pending = s.pending[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// fill formats the next chunk of values into pending, or the end of
+// the text and the error to finish with.
+func (s *csvReader) fill() {
+	buf := s.pending[:0]
+	n, err := s.d.Read(s.tmp)
+	for _, d := range s.tmp[:n] {
+		s.val += d
+		if !s.first {
+			buf = append(buf, ',')
+		}
+		s.first = false
+		buf = strconv.AppendInt(buf, int64(s.val), 10)
+	}
+	if err == io.EOF && s.d.truncated() {
+		s.err = io.ErrUnexpectedEOF
+	} else if err == io.EOF {
+		buf = append(buf, '\n')
+		s.err = io.EOF
+	} else if err != nil {
+		s.err = err
+	}
+	s.pending = buf
+}
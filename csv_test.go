@@ -0,0 +1,51 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodeCSVReader(t *testing.T) {
+	data := []int{-5, 0, 17, -1 << 63, 1<<63 - 1, 3}
+	for i := 0; i < 1000; i++ {
+		data = append(data, rand.Intn(2001)-1000)
+	}
+	compressed := DeltaEncode(4, data)
+	want := DeltaDecode(4, compressed)
+
+	// Whole, and through one-byte Reads that split every number.
+	for _, r := range []io.Reader{DecodeCSVReader(4, compressed), iotest.OneByteReader(DecodeCSVReader(4, compressed))} {
+		text, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasSuffix(text, []byte{'\n'}) {
+			t.Fatal("No trailing newline")
+		}
+		fields := strings.Split(strings.TrimSuffix(string(text), "\n"), ",")
+		if len(fields) != len(want) {
+			t.Fatalf("Got %d fields, want %d", len(fields), len(want))
+		}
+		for i, f := range fields {
+			v, err := strconv.Atoi(f)
+			if err != nil || v != want[i] {
+				t.Fatalf("Field %d was %q, expected %d", i, f, want[i])
+			}
+		}
+	}
+
+	if text, err := ioutil.ReadAll(DecodeCSVReader(4, nil)); err != nil || string(text) != "\n" {
+		t.Fatalf("Empty stream gave %q, %v", text, err)
+	}
+	// 9 and 0, then a cut-off codeword.
+	text, err := ioutil.ReadAll(DecodeCSVReader(0, []byte{0x14, 0x80, 0x00}))
+	if err != io.ErrUnexpectedEOF || string(text) != "9,9" {
+		t.Fatalf("Truncated stream gave %q, %v", text, err)
+	}
+}
@@ -23,25 +23,68 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"io"
+	"math"
+	"math/bits"
+)
+
+var (
+	ErrBitOrder      = errors.New("deltagolomb: codeword exceeds MaxBits; stream may be LSB-first or corrupt")
+	ErrTooManyValues = errors.New("deltagolomb: stream holds more values than allowed")
+	ErrOverflow      = errors.New("deltagolomb: value does not fit the output type")
+	ErrRawWidth      = errors.New("deltagolomb: raw bit field wider than 64 bits")
+	ErrNegativeCount = errors.New("deltagolomb: negative repeat count")
+	ErrClosed        = errors.New("deltagolomb: write to closed encoder")
+	ErrCountMismatch = errors.New("deltagolomb: stream does not hold the expected number of values")
+	ErrPadding       = errors.New("deltagolomb: stream does not end in zero padding")
 )
 
 type ExpGolombDecoder struct {
-	r     byteReader
+	r     io.ByteReader
 	b     byte
 	state int
 	val   int
 	zeros int
 	nBits int
+
+	codeBits int   // length of the codeword being decoded, sans sign
+	stats    []int // codeword length histogram, nil unless enabled
+	mode     SignMode
+	maxBits  int   // zero prefix limit, 0 for none
+	consumed int   // bytes taken from r
+	ended    bool  // terminator seen
+	feedErr  error // first error met by Feed
 }
 
 const egWordBits = 64
 
+// The zero prefix of the terminator, one longer than any value's.
+const terminatorZeros = 65
+
+// Slices sized from a count read off the stream start out no longer
+// than this and grow as values arrive, so a corrupt count costs no more
+// memory than the data behind it.
+const maxPrealloc = 4096
+
+// An ExpGolombEncoder must be created with NewExpGolombEncoder or one
+// of its variants.  The zero value has no writer; using it panics.
 type ExpGolombEncoder struct {
 	data     uint64
 	bitsleft uint
 	out      byteWriter
 	outbuf   []byte
+	mode     SignMode
+	err      error // first error from the underlying writer
+	written  int   // bytes passed to out since the last Flush
+	values   int   // values encoded, for ValuesWritten
+	flushed  int   // bytes passed to out, for BytesFlushed
+	slowPath bool  // skip the smallCodes table, see UseFastPath
+	autoSize int   // Flush once this many bytes are held, 0 for never
+	closed   bool
+	closeErr error // what the first Close returned
+	endMark  bool  // Close writes a terminator, see UseTerminator
+	cp       checkpoint
 }
 
 // Create a new Exp-Golomb stream Encoder.
@@ -49,8 +92,29 @@ type ExpGolombEncoder struct {
 // the resulting byte stream to w.  Users must call Close()
 // when finished to ensure that all bytes are written to w.
 func NewExpGolombEncoder(w io.Writer) *ExpGolombEncoder {
-	ww := makeWriter(w)
-	return &ExpGolombEncoder{0, egWordBits, ww, make([]byte, 8)}
+	return newEncoder(makeWriter(w, 0))
+}
+
+// Create a new Exp-Golomb stream Encoder as NewExpGolombEncoder does,
+// but if w has to be wrapped in a bufio.Writer, give it a buffer of
+// bufSize bytes.  Larger buffers mean fewer writes to w.
+func NewExpGolombEncoderSize(w io.Writer, bufSize int) *ExpGolombEncoder {
+	return newEncoder(makeWriter(w, bufSize))
+}
+
+// Create a new Exp-Golomb stream Encoder as NewExpGolombEncoder does,
+// but flush after any value that leaves thresholdBytes or more bytes
+// held in the encoder and its buffer, to bound latency on a network
+// stream.  Flush writes whole bytes only, so the receiving decoder
+// sees an ordinary stream, just delivered in pieces.
+func NewExpGolombEncoderAutoFlush(w io.Writer, thresholdBytes int) *ExpGolombEncoder {
+	s := newEncoder(makeWriter(w, thresholdBytes))
+	s.autoSize = thresholdBytes
+	return s
+}
+
+func newEncoder(ww byteWriter) *ExpGolombEncoder {
+	return &ExpGolombEncoder{bitsleft: egWordBits, out: ww, outbuf: make([]byte, 8)}
 }
 
 // Create a new Exp-Golomb stream decoder.  Callers can read
@@ -62,9 +126,17 @@ func NewExpGolombDecoder(r io.Reader) *ExpGolombDecoder {
 	return d
 }
 
+// NewExpGolombDecoderByteReader is NewExpGolombDecoder for a source
+// that can already deliver single bytes.  The decoder never needs
+// more than ReadByte, so r is used as is and never buffered.
+func NewExpGolombDecoderByteReader(r io.ByteReader) *ExpGolombDecoder {
+	return &ExpGolombDecoder{r: r}
+}
+
 // Helper function stolen from compress/flate/inflate.go
 // If the passed in reader does not support ReadByte(), wrap
-// it in a bufio.
+// it in a bufio.  *bytes.Reader, *bytes.Buffer and *bufio.Reader
+// all pass through unwrapped.
 type byteReader interface {
 	io.Reader
 	ReadByte() (c byte, err error)
@@ -72,7 +144,7 @@ type byteReader interface {
 
 // Analogous helper for byte-at-a-time output.
 // If the passed in writer does not support WriteByte(),
-// wrap it in a bufio.
+// wrap it in a bufio.  Flush is optional; see makeWriter.
 type byteWriter interface {
 	io.Writer
 	WriteByte(c byte) error
@@ -86,11 +158,25 @@ func makeReader(r io.Reader) byteReader {
 	return bufio.NewReader(r)
 }
 
-func makeWriter(w io.Writer) byteWriter {
+// Writers such as *bytes.Buffer already accept single bytes and have
+// nothing to flush.  Give them a no-op Flush rather than stacking a
+// bufio.Writer on top.
+type nopFlusher struct {
+	io.Writer
+	io.ByteWriter
+}
+
+func (nopFlusher) Flush() error { return nil }
+
+// A size of zero or less gets bufio's default buffer.
+func makeWriter(w io.Writer, size int) byteWriter {
 	if ww, ok := w.(byteWriter); ok {
 		return ww
 	}
-	return bufio.NewWriter(w)
+	if bw, ok := w.(io.ByteWriter); ok {
+		return nopFlusher{w, bw}
+	}
+	return bufio.NewWriterSize(w, size)
 }
 
 // Decode states, bit-at-a-time (slow but safe)
@@ -105,39 +191,417 @@ const (
 // until the encoder is Close()'d.
 
 func (s *ExpGolombEncoder) Write(ilist []int) {
+	if s.isClosed() {
+		return
+	}
 	for _, i := range ilist {
 		s.add(i)
+		if s.autoSize > 0 {
+			s.autoFlush()
+		}
 	}
 }
 
 // Encode a single signed integer into the byte stream.
 func (s *ExpGolombEncoder) WriteInt(i int) {
+	if s.isClosed() {
+		return
+	}
 	s.add(i)
+	if s.err != nil && s.cp.set {
+		s.restore()
+	}
+	if s.autoSize > 0 {
+		s.autoFlush()
+	}
+}
+
+// Encode value count times.  A count of zero writes nothing; a
+// negative count writes nothing and returns ErrNegativeCount.
+func (s *ExpGolombEncoder) WriteRepeated(value int, count int) error {
+	if count < 0 {
+		return ErrNegativeCount
+	}
+	if s.isClosed() {
+		return ErrClosed
+	}
+	for ; count > 0; count-- {
+		s.add(value)
+		if s.autoSize > 0 {
+			s.autoFlush()
+		}
+	}
+	return nil
+}
+
+// Encode a single unsigned integer into the byte stream.  Unsigned
+// codewords carry no sign bit, so they must be read back with
+// ReadUnsigned.
+func (s *ExpGolombEncoder) WriteUnsigned(u uint) {
+	if s.isClosed() {
+		return
+	}
+	s.values++
+	s.addUnsigned(u)
+	if s.err != nil && s.cp.set {
+		s.restore()
+	}
+	if s.autoSize > 0 {
+		s.autoFlush()
+	}
+}
+
+// UseFastPath turns the small-value codeword table on or off, so that
+// its effect on a given workload can be measured.  It is on by
+// default.  The bytes written are the same either way.
+func (s *ExpGolombEncoder) UseFastPath(on bool) {
+	s.slowPath = !on
+}
+
+// UseTerminator makes Close write a terminator codeword before the
+// padding, so that a decoder stops at the end of the stream even when
+// other data follows it:  Read returns io.EOF at the terminator, and
+// BytesConsumed gives the offset of whatever comes next.  The
+// terminator is 65 zeros and a one.  No value in any sign mode has a
+// codeword with that many zeros, since even the largest uint needs
+// only 64, so it cannot be mistaken for a value.  Decoders always
+// recognise it, unless SetMaxBits has set a limit below 65.
+func (s *ExpGolombEncoder) UseTerminator(on bool) {
+	s.endMark = on
+}
+
+// ValuesWritten returns how many values have been encoded since the
+// encoder was created.  Like the encoder it is not safe for
+// concurrent use.
+func (s *ExpGolombEncoder) ValuesWritten() int {
+	return s.values
+}
+
+// BytesFlushed returns how many bytes the encoder has handed to its
+// writer since it was created, including the padded final byte once
+// Close has run.  Bytes still in the encoder's own accumulator are not
+// counted; if the writer was wrapped in a bufio.Writer, bytes it holds
+// are.
+func (s *ExpGolombEncoder) BytesFlushed() int {
+	return s.flushed
+}
+
+// Write the low n bits of bits into the stream as a raw field, most
+// significant bit first, with no Exp-Golomb structure; ReadRawBits
+// reads it back.  Higher bits are ignored, and a width over 64 is
+// padded with leading zeros.
+func (s *ExpGolombEncoder) WriteRawBits(bits uint64, n uint) {
+	if s.isClosed() {
+		return
+	}
+	if n < 64 {
+		bits &= 1<<n - 1
+	}
+	if n > 32 {
+		// Halves, so that a 32-bit uint can carry them.
+		s.addBits(uint(bits>>32), n-32)
+		n = 32
+	}
+	s.addBits(uint(bits&0xffffffff), n)
 }
 
-func (s *ExpGolombEncoder) Close() {
+// Write n in unary, as n zero bits and a one, the same as the prefix
+// of an Exp-Golomb codeword; ReadUnary reads it back.
+func (s *ExpGolombEncoder) WriteUnary(n uint) {
+	if s.isClosed() {
+		return
+	}
+	s.addZeroBits(n)
+	s.addBits(1, 1)
+}
+
+// WriteAll encodes vals like Write, but stops at the first error from
+// the underlying writer.  Bits still in the encoder when a write fails
+// are lost, so committed counts only the values whose codewords lie
+// wholly in bytes the writer accepted; those bytes may end with the
+// start of vals[committed], and a caller can retry from there.  With
+// no error every value is committed, though some may still be
+// buffered.
+func (s *ExpGolombEncoder) WriteAll(vals []int) (committed int, err error) {
+	if s.isClosed() || s.err != nil {
+		return 0, s.err
+	}
+	// End bit offsets, counted from the first byte the encoder wrote,
+	// of the values not yet wholly accepted by the writer.
+	ends := make([]int, 0, egWordBits+1)
+	for _, v := range vals {
+		s.add(v)
+		if s.autoSize > 0 {
+			s.autoFlush()
+		}
+		if s.err != nil {
+			for _, end := range ends {
+				if end > 8*s.flushed {
+					break
+				}
+				committed++
+			}
+			return committed, s.err
+		}
+		ends = append(ends, 8*s.flushed+int(egWordBits-s.bitsleft))
+		for len(ends) > 0 && ends[0] <= 8*s.flushed {
+			ends = ends[1:]
+			committed++
+		}
+	}
+	return len(vals), nil
+}
+
+// Flush pushes every complete byte encoded so far through to the
+// underlying writer and returns how many bytes reached it since the
+// previous Flush.  The decoder cannot tell padding from the zero
+// prefix of the next codeword, so Flush does not pad:  up to seven
+// bits of a final partial byte stay buffered until more values
+// complete it or Close pads it.  Encoding may continue afterwards.
+func (s *ExpGolombEncoder) Flush() (int, error) {
+	if s.isClosed() {
+		return 0, s.err
+	}
+	if nbytes := (egWordBits - s.bitsleft) / 8; nbytes > 0 {
+		s.emit(nbytes)
+	}
+	if err := s.out.Flush(); err != nil && s.err == nil {
+		s.err = err
+	}
+	n := s.written
+	s.written = 0
+	return n, s.err
+}
+
+// Close writes out any partial word, padded with zero bits, and
+// flushes the underlying writer.  Returns the first error the
+// underlying writer reported during encoding, if any.  Closing again
+// does nothing and returns the same result.  Once closed, the encoder
+// drops further values; WriteAll, WriteRepeated and Flush report
+// ErrClosed.
+func (s *ExpGolombEncoder) Close() error {
+	if s.closed {
+		return s.closeErr
+	}
+	if s.endMark {
+		s.addZeroBits(terminatorZeros)
+		s.addBits(1, 1)
+	}
 	if s.bitsleft != egWordBits {
 		s.emitPartialBits()
 	}
-	s.out.Flush()
+	if err := s.out.Flush(); err != nil && s.err == nil {
+		s.err = err
+	}
+	s.closed = true
+	s.closeErr = s.err
+	return s.err
+}
+
+// isClosed reports whether Close has run, recording ErrClosed as the
+// encoder's error if it has.
+func (s *ExpGolombEncoder) isClosed() bool {
+	if !s.closed {
+		return false
+	}
+	if s.err == nil {
+		s.err = ErrClosed
+	}
+	return true
 }
 
 // Decode a byte-stream of exp-golomb coded signed integers.
 // Reads all available bytes from 'in';
 // Emits decoded integers to 'out'.
+// Read keeps reading until out is full, returning (len(out), nil),
+// or until the reader fails, returning the values decoded so far
+// with the reader's error, normally io.EOF.  Both can happen at once:
+// the last values may arrive together with io.EOF, so check n before
+// err.  Use ReadFull when exactly len(out) values are expected.
+// An empty out returns (0, nil) without touching the stream.
 func (s *ExpGolombDecoder) Read(out []int) (int, error) {
+	if s.mode == ZigZag {
+		n, err := decode(s, out, true)
+		for i := range out[:n] {
+			out[i] = unZigZag(uint(out[i]))
+		}
+		return n, err
+	}
+	if s.mode == Positive {
+		// One value at a time, so that a value too large to take the
+		// +1 stops the read without consuming any after it.
+		var tmp [1]int
+		for i := range out {
+			if n, err := decode(s, tmp[:], true); n == 0 {
+				return i, err
+			}
+			if uint(tmp[0]) >= math.MaxInt {
+				return i, ErrOverflow
+			}
+			out[i] = tmp[0] + 1
+		}
+		return len(out), nil
+	}
+	return decode(s, out, false)
+}
+
+// ReadFull fills out completely, as io.ReadFull does for bytes.  If
+// the stream ends first the error is io.ErrUnexpectedEOF; any other
+// reader error is returned as is.  Values beyond len(out) are left in
+// the stream for later reads.
+func (s *ExpGolombDecoder) ReadFull(out []int) error {
+	for got := 0; got < len(out); {
+		n, err := s.Read(out[got:])
+		got += n
+		if err != nil && got < len(out) {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// readN reads exactly n values with read, which is a decoder's Read
+// or ReadUnsigned.  The result grows as values arrive rather than
+// being sized from n, which usually comes from the stream itself.  If
+// the stream ends first the error is io.ErrUnexpectedEOF.
+func readN[T int | uint](n uint, read func([]T) (int, error)) ([]T, error) {
+	size := n
+	if size > maxPrealloc {
+		size = maxPrealloc
+	}
+	res := make([]T, 0, size)
+	for uint(len(res)) < n {
+		if len(res) == cap(res) {
+			res = append(res, 0)[:len(res)]
+		}
+		end := cap(res)
+		if uint(end) > n {
+			end = int(n)
+		}
+		got, err := read(res[len(res):end])
+		res = res[:len(res)+got]
+		if err != nil && uint(len(res)) < n {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// ReadInt32 decodes like Read, but into an int32 slice, for values
+// known to fit in 32 bits.  A value that does not fit stops the read
+// with ErrOverflow; it is consumed from the stream but not stored, and
+// the returned count covers the values before it.
+func (s *ExpGolombDecoder) ReadInt32(out []int32) (int, error) {
+	if s.mode != SignBit {
+		// One value at a time, so an overflow consumes no more.
+		tmp := make([]int, 1)
+		for i := range out {
+			n, err := s.Read(tmp)
+			if n == 1 {
+				if int(int32(tmp[0])) != tmp[0] {
+					return i, ErrOverflow
+				}
+				out[i] = int32(tmp[0])
+				n = i + 1
+			} else {
+				n = i
+			}
+			if err != nil {
+				return n, err
+			}
+		}
+		return len(out), nil
+	}
+	return decode(s, out, false)
+}
+
+// Decode a byte-stream of unsigned exp-golomb codewords, as written
+// by WriteUnsigned.  These carry no sign bit.
+func (s *ExpGolombDecoder) ReadUnsigned(out []uint) (int, error) {
+	return decode(s, out, true)
+}
+
+// Feed decodes as many complete values as possible from chunk and
+// returns them.  A codeword left incomplete at the end of chunk is
+// held in the decoder and finished by the next call, so a stream may
+// be fed in arbitrary pieces as they arrive.  Feed does not read from
+// the decoder's own io.Reader; a decoder used only for feeding can be
+// created with NewExpGolombDecoder(nil).
+//
+// A decoding error such as ErrOverflow stops Feed:  it returns the
+// values before the error, and later calls return nothing.  Check Err
+// after feeding.
+func (s *ExpGolombDecoder) Feed(chunk []byte) []int {
+	res := make([]int, 0)
+	if s.feedErr != nil {
+		return res
+	}
+	saved := s.r
+	s.r = bytes.NewReader(chunk)
+	defer func() { s.r = saved }()
+
+	tmp := make([]int, 256)
+	for {
+		n, err := s.Read(tmp)
+		res = append(res, tmp[:n]...)
+		if err == io.EOF {
+			// The end of chunk, or a terminator.
+			return res
+		} else if err != nil {
+			s.feedErr = err
+			return res
+		}
+	}
+}
+
+// Err returns the error that stopped Feed, or nil if there was none.
+// Running out of a chunk, or reaching a terminator, is not an error.
+func (s *ExpGolombDecoder) Err() error {
+	return s.feedErr
+}
+
+// decode runs the bit-at-a-time state machine shared by Read and
+// ReadUnsigned.  When unsigned is set, no sign bit follows the
+// magnitude.
+func decode[T int | uint | int32](s *ExpGolombDecoder, out []T, unsigned bool) (int, error) {
 	cpos := 0
 	n := len(out)
+	if n == 0 {
+		return 0, nil
+	}
+	if s.ended {
+		return 0, io.EOF
+	}
 
 	for {
 		if s.nBits == 0 {
+			if cpos >= n {
+				// Don't read ahead of the last value.
+				return cpos, nil
+			}
 			var readError error
 			s.b, readError = s.r.ReadByte()
 			if readError != nil {
 				return cpos, readError
 			} else {
 				s.nBits = 8
+				s.consumed++
+			}
+		}
+		if s.b == 0 && s.nBits == 8 && s.state == COUNTING_ZEROS && cpos < n {
+			// A whole byte of prefix zeros:  count it in one step.
+			s.zeros += 8
+			s.nBits = 0
+			if s.maxBits > 0 && s.zeros > s.maxBits {
+				return cpos, ErrBitOrder
 			}
+			continue
 		}
 		for s.nBits > 0 {
 			if cpos >= n {
@@ -149,14 +613,37 @@ func (s *ExpGolombDecoder) Read(out []int) (int, error) {
 			switch s.state {
 			case COUNTING_ZEROS:
 				if bit == 0 {
-					s.zeros++
+					// Take the rest of the zero run in this byte
+					// in one step, leaving the one that ends it.
+					lz := bits.LeadingZeros8(s.b << uint(8-s.nBits))
+					if lz > s.nBits {
+						lz = s.nBits
+					}
+					s.zeros += 1 + lz
+					s.nBits -= lz
+					if s.maxBits > 0 && s.zeros > s.maxBits {
+						return cpos, ErrBitOrder
+					}
 				} else {
 					if s.zeros == 0 {
 						out[cpos] = 0
 						cpos++
+						if s.stats != nil {
+							s.tally(1)
+						}
 					} else {
+						if s.zeros == terminatorZeros {
+							s.zeros = 0
+							s.ended = true
+							return cpos, io.EOF
+						}
+						if s.zeros > 64 || s.zeros == 64 && !unsigned {
+							// The magnitude would not fit in 64 bits.
+							return cpos, ErrOverflow
+						}
 						s.state = SHIFTING_BITS
 						s.val = 1
+						s.codeBits = 2*s.zeros + 1
 					}
 				}
 			case SHIFTING_BITS:
@@ -165,22 +652,207 @@ func (s *ExpGolombDecoder) Read(out []int) (int, error) {
 				s.zeros--
 				if s.zeros == 0 {
 					s.val -= 1 // Because we stole bit for 0.
-					s.state = COUNTING_ZEROS 
-					out[cpos] = s.val
-					cpos++
+					if unsigned {
+						v := T(s.val)
+						s.state = COUNTING_ZEROS
+						// With 64 zeros only the largest uint, whose
+						// bits after the leading one are all zero, fits.
+						if int(v) != s.val || s.codeBits > 128 && s.val != -1 {
+							return cpos, ErrOverflow
+						}
+						out[cpos] = v
+						cpos++
+						if s.stats != nil {
+							s.tally(s.codeBits)
+						}
+					} else {
+						s.state = READING_SIGN
+					}
 				}
 			case READING_SIGN:
+				// A magnitude of 2^63 or more has wrapped negative;
+				// only -2^63 itself fits.
+				if s.val < 0 && (bit == 0 || s.val != math.MinInt) {
+					return cpos, ErrOverflow
+				}
 				if bit == 1 {
 					s.val = -s.val
 				}
-				out[cpos] = s.val
-				cpos++
+				v := T(s.val)
 				s.state = COUNTING_ZEROS
+				if int(v) != s.val {
+					return cpos, ErrOverflow
+				}
+				out[cpos] = v
+				cpos++
+				if s.stats != nil {
+					s.tally(s.codeBits + 1)
+				}
 			}
 		}
 	}
-	// If we run off the end, do not emit the value.
-	return 0, nil // NOTREACHED
+}
+
+// SetMaxBits tells the decoder that no value is expected to reach
+// 2^n in magnitude.  A codeword whose zero prefix is longer than n
+// then makes Read fail with ErrBitOrder rather than return garbage.
+// Such prefixes typically come from a stream written LSB-first.
+// n of zero disables the check, which is the default.
+func (s *ExpGolombDecoder) SetMaxBits(n int) {
+	s.maxBits = n
+}
+
+// DrainChecksum decodes everything left in the stream without keeping
+// it, returning the number of values and the XOR of all of them as a
+// cheap signature.  For a delta-coded stream these are the residuals,
+// not the absolute values.  A stream that ends partway through a
+// codeword returns io.ErrUnexpectedEOF along with the totals so far.
+func (s *ExpGolombDecoder) DrainChecksum() (count int, xor int, err error) {
+	tmp := make([]int, 256)
+	for {
+		n, err := s.Read(tmp)
+		for _, v := range tmp[:n] {
+			xor ^= v
+		}
+		count += n
+		if err == io.EOF {
+			if s.truncated() {
+				err = io.ErrUnexpectedEOF
+			} else {
+				err = nil
+			}
+			return count, xor, err
+		} else if err != nil {
+			return count, xor, err
+		}
+	}
+}
+
+// CheckPadding verifies that the stream ends cleanly after the values
+// read so far:  that the rest of the current byte holds only zero
+// bits, and that no bytes follow it.  Otherwise it returns
+// ErrPadding.  A stream that ends on a byte boundary has no padding,
+// so only the second check applies.  Call it after reading exactly
+// the values expected, with ReadFull for example; since any bits
+// parse as codewords, a stray 1 in the padding of a stream read to
+// its end would already have been decoded as a value.  Reading ahead
+// for more bytes consumes one if it is there.
+func (s *ExpGolombDecoder) CheckPadding() error {
+	if s.state != COUNTING_ZEROS || s.zeros != 0 {
+		return ErrPadding
+	}
+	if s.b&(1<<uint(s.nBits)-1) != 0 {
+		return ErrPadding
+	}
+	if _, err := s.r.ReadByte(); err == nil {
+		s.consumed++
+		return ErrPadding
+	} else if err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// truncated reports whether the bits consumed so far end partway
+// through a codeword.  Fewer than eight zeros may be the padding
+// after the final codeword, so they don't count.
+func (s *ExpGolombDecoder) truncated() bool {
+	return s.state != COUNTING_ZEROS || s.zeros >= 8
+}
+
+// EnableStats turns on the codeword length histogram returned by
+// DecoderStats.  Only values decoded after the call are counted.
+func (s *ExpGolombDecoder) EnableStats() {
+	if s.stats == nil {
+		s.stats = make([]int, 2)
+	}
+}
+
+// DecoderStats returns how many codewords of each bit length have
+// been decoded:  element i counts codewords that were i bits long,
+// including the sign bit.  Returns nil if stats are not enabled.
+func (s *ExpGolombDecoder) DecoderStats() []int {
+	return s.stats
+}
+
+func (s *ExpGolombDecoder) tally(nbits int) {
+	for len(s.stats) <= nbits {
+		s.stats = append(s.stats, 0)
+	}
+	s.stats[nbits]++
+}
+
+// readBit pulls a single raw bit from the stream.  It is only
+// meaningful between codewords, i.e. in COUNTING_ZEROS with no
+// zeros counted yet.
+func (s *ExpGolombDecoder) readBit() (uint, error) {
+	if s.nBits == 0 {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		s.b = b
+		s.nBits = 8
+		s.consumed++
+	}
+	s.nBits--
+	return uint(s.b>>uint(s.nBits)) & 0x01, nil
+}
+
+// BytesConsumed returns how many bytes the decoder has taken from its
+// reader, counting the byte that holds the last bit decoded.  Reads
+// never fetch a byte before it is needed, so after decoding a known
+// number of values any data following the Exp-Golomb region starts
+// BytesConsumed bytes in, just past the padding.
+func (s *ExpGolombDecoder) BytesConsumed() int {
+	return s.consumed
+}
+
+// ReadRawBits reads an n-bit unsigned field, most significant bit
+// first, from the current position in the stream, for headers and
+// flags mixed in with the codewords.  It must be called between
+// values, never partway through a codeword.  If the stream ends
+// before any bit is read the error is io.EOF; if it ends partway
+// through the field it is io.ErrUnexpectedEOF.
+func (s *ExpGolombDecoder) ReadRawBits(n uint) (uint64, error) {
+	if n > 64 {
+		return 0, ErrRawWidth
+	}
+	v := uint64(0)
+	for i := uint(0); i < n; i++ {
+		bit, err := s.readBit()
+		if err != nil {
+			if err == io.EOF && i > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		v = v<<1 | uint64(bit)
+	}
+	return v, nil
+}
+
+// ReadUnary reads a count written by WriteUnary:  the number of zero
+// bits before the next one.  Like ReadRawBits it must be called
+// between values.  If the stream ends before the one, the error is
+// io.EOF when fewer than eight zeros were read, since as with Read
+// they may be the padding after the last codeword, and
+// io.ErrUnexpectedEOF otherwise.
+func (s *ExpGolombDecoder) ReadUnary() (uint, error) {
+	n := uint(0)
+	for {
+		bit, err := s.readBit()
+		if err != nil {
+			if err == io.EOF && n >= 8 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if bit == 1 {
+			return n, nil
+		}
+		n++
+	}
 }
 
 // Exponential golomb coding with an explicit sign bit for everything
@@ -198,30 +870,78 @@ func (s *ExpGolombDecoder) Read(out []int) (int, error) {
 
 // Add implements the actual encoding of a single value.  Emits
 // zero or more bytes onto the 'out' stream as they are filled.
-// Note:  This function is only safe for values up to += 2^31 - 2,
-// not 2^31 - 1 as you might expect.  Rewrite to take uint64s if
-// needed for larger values.
+// Every int is encodable; the largest magnitudes produce codewords
+// of up to 128 bits.
 
 func (s *ExpGolombEncoder) add(item int) {
-	// Quick optimization for the most common values we expect to encode.
-	// This has an obvious generalization to a small table if desired.
-	switch item {
-	case 0:
-		s.addBits(1, 1)
-		return
-	case 1:
-		s.addBits(0x4, 4)
+	s.values++
+	if s.mode == ZigZag {
+		s.addUnsigned(zigZag(item))
 		return
-	case -1:
-		s.addBits(0x5, 4)
-		return
-	case 2:
-		s.addBits(0x6, 4)
+	}
+	if s.mode == Positive {
+		if item < 1 {
+			s.values-- // skipped, not encoded
+			if s.err == nil {
+				s.err = ErrPositiveMode
+			}
+			return
+		}
+		s.addUnsigned(uint(item - 1))
 		return
-	case -2:
-		s.addBits(0x7, 4)
+	}
+	// Quick optimization for the most common values we expect to encode.
+	if item >= -smallCodeMax && item <= smallCodeMax && !s.slowPath {
+		c := smallCodes[item+smallCodeMax]
+		s.addBits(c.bits, c.nbits)
 		return
+	}
+	s.addGeneral(item)
+}
+
+// smallCodes holds the codewords for -smallCodeMax..smallCodeMax,
+// indexed by value+smallCodeMax.  Residuals cluster here.
+const smallCodeMax = 8
+
+var smallCodes = [2*smallCodeMax + 1]struct{ bits, nbits uint }{
+	{0x13, 8}, // -8: 0001 001 1
+	{0x11, 8}, // -7: 0001 000 1
+	{0xF, 6},  // -6: 001 11 1
+	{0xD, 6},  // -5: 001 10 1
+	{0xB, 6},  // -4: 001 01 1
+	{0x9, 6},  // -3: 001 00 1
+	{0x7, 4},  // -2: 01 1 1
+	{0x5, 4},  // -1: 01 0 1
+	{0x1, 1},  // 0: 1
+	{0x4, 4},  // 1: 01 0 0
+	{0x6, 4},  // 2: 01 1 0
+	{0x8, 6},  // 3: 001 00 0
+	{0xA, 6},  // 4: 001 01 0
+	{0xC, 6},  // 5: 001 10 0
+	{0xE, 6},  // 6: 001 11 0
+	{0x10, 8}, // 7: 0001 000 0
+	{0x12, 8}, // 8: 0001 001 0
+}
+
+// autoFlush flushes if at least autoSize bytes are held back from the
+// underlying writer.
+func (s *ExpGolombEncoder) autoFlush() {
+	held := int(egWordBits-s.bitsleft) / 8
+	if bw, ok := s.out.(*bufio.Writer); ok {
+		held += bw.Buffered()
+	}
+	if held >= s.autoSize {
+		s.Flush()
+	}
+}
 
+// addGeneral encodes any value without the small-value shortcuts
+// above.  The shortcuts must stay byte-identical to it.
+func (s *ExpGolombEncoder) addGeneral(item int) {
+	// Zero is the only codeword without a sign bit.
+	if item == 0 {
+		s.addBits(1, 1)
+		return
 	}
 
 	sign := uint(0)
@@ -234,37 +954,85 @@ func (s *ExpGolombEncoder) add(item int) {
 	uitem += 1 // we stole a bit for zero.
 	nbits := uint(bitLen(uitem)) - 1
 	s.addZeroBits(nbits)
+	if nbits+2 > egWordBits {
+		// Magnitude and sign don't fit in one word together.
+		s.addBits(uitem, nbits+1)
+		s.addBits(sign, 1)
+		return
+	}
 	uitem = (uitem << 1) | sign
 	s.addBits(uitem, nbits+2) // +1 high order, +1 sign
 	return
 }
 
+// addUnsigned is add without the sign bit:  the plain order-zero
+// Exp-Golomb code.
+func (s *ExpGolombEncoder) addUnsigned(u uint) {
+	u += 1 // we stole a bit for zero.
+	if u == 0 {
+		// u was the largest uint, so u+1 needs 65 bits:  a one
+		// followed by 64 zeros.
+		s.addZeroBits(egWordBits)
+		s.addBits(1, 1)
+		s.addZeroBits(egWordBits)
+		return
+	}
+	nbits := uint(bitLen(u)) - 1
+	s.addZeroBits(nbits)
+	s.addBits(u, nbits+1) // +1 high order
+}
+
 func (s *ExpGolombEncoder) emitPartialBits() {
-	var b [8]byte
-	var bs = b[:8]
-	// The slowness here makes me crave an optimized htonll function.
-	binary.BigEndian.PutUint64(bs, s.data)
-	nbytes := ((egWordBits - s.bitsleft) + 7) / 8
-	if nbytes > 0 {
-		s.out.Write(bs[:nbytes])
+	if nbytes := ((egWordBits - s.bitsleft) + 7) / 8; nbytes > 0 {
+		s.emit(nbytes)
 	}
 	s.data = 0
 	s.bitsleft = egWordBits
 }
 
+// emitBits writes out the whole accumulator, which the caller has
+// filled, whatever bitsleft says.
 func (s *ExpGolombEncoder) emitBits() {
+	s.emit(8)
+	s.data = 0
+	s.bitsleft = egWordBits
+}
+
+// emit writes the top nbytes bytes of the accumulator and shifts
+// them out.  Every path to the writer goes through here.
+func (s *ExpGolombEncoder) emit(nbytes uint) {
+	if s.out == nil {
+		panic("deltagolomb: ExpGolombEncoder has no writer; create it with NewExpGolombEncoder")
+	}
 	// The overhead of allocating and freeing the outbuf slice
 	// makes it worth pre-allocating in the struct.
 	binary.BigEndian.PutUint64(s.outbuf, s.data)
-	s.out.Write(s.outbuf)
-	s.data = 0
-	s.bitsleft = egWordBits
+	s.write(s.outbuf[:nbytes])
+	// A shift by egWordBits clears data, as Go defines it.
+	s.data <<= nbytes * 8
+	s.bitsleft += nbytes * 8
+}
+
+// write passes b to the underlying writer, remembering the first
+// error.
+func (s *ExpGolombEncoder) write(b []byte) {
+	n, err := s.out.Write(b)
+	s.written += n
+	s.flushed += n
+	if err != nil && s.err == nil {
+		s.err = err
+	}
 }
 
 // Helper function that adds nbits bit to the output byte stream.
 // Emits the byte(s) if they are full, otherwise just updates internal
-// state.
+// state.  bits holds at most egWordBits bits; if nbits is larger the
+// excess is taken as leading zeros.
 func (s *ExpGolombEncoder) addBits(bits uint, nbits uint) {
+	if nbits > egWordBits {
+		s.addZeroBits(nbits - egWordBits)
+		nbits = egWordBits
+	}
 	if nbits < s.bitsleft {
 		s.data |= (uint64(bits) << (s.bitsleft - nbits))
 		s.bitsleft -= nbits
@@ -272,16 +1040,12 @@ func (s *ExpGolombEncoder) addBits(bits uint, nbits uint) {
 	} else {
 		s.data |= uint64(bits >> (nbits - s.bitsleft))
 		nbits -= s.bitsleft
-		// This next line only matters in the future
-		//bits &= ((1 << nbits)-1) // zero out the bits we just consumed
 		s.emitBits()
 	}
 
-	// This code will never be executed when using 64 bit words.
-	//for ; nbits > egWordBits; nbits -= egWordBits {
-	//	s.data = uint64(bits >> (nbits - egWordBits))
-	//	s.emitBits()
-	//}
+	// The high bits of bits were consumed above and shift out here.
+	// If nbits is now zero the shift is by egWordBits, which Go
+	// defines to produce zero.
 	s.data = uint64(bits) << (egWordBits - nbits)
 	s.bitsleft = egWordBits - nbits
 }
@@ -307,71 +1071,151 @@ func (s *ExpGolombEncoder) addZeroBits(nzeros uint) {
 }
 
 // Computes the number of bits needed to represent a value.
-func bitLen(x uint) (n int) {
-	if x > (1<<31) {
-		x >>= 32
-		n += 32
-	}
-	if x >= 0x8000 {
-		x >>= 16
-		n += 16
-	}
-	if x >= 0x80 {
-		x >>= 8
-		n += 8
-	}
-	if x >= 0x8 {
-		x >>= 4
-		n += 4
-	}
-	if x >= 0x2 {
-		x >>= 2
-		n += 2
-	}
-	if x >= 0x1 {
-		n++
-	}
-	return
+func bitLen(x uint) int {
+	return bits.Len(x)
 }
 
 // Delta encodes an array of integers and then uses Exp-Golomb to
 // encode the residuals.  Returns the encoded byte stream of residuals
 // as a byte array.
 // DeltaEncode uses the value of 'start' to encode the first value
-// as value - start.
+// as value - start.  Empty data encodes to zero bytes.
 func DeltaEncode(start int, data []int) []byte {
 	bytestream := &bytes.Buffer{}
 	egs := NewExpGolombEncoder(bytestream)
 
+	// Same residuals as Deltas, without materializing the slice.
 	prev := start
 	for _, i := range data {
-		delta := i - prev
+		egs.WriteInt(i - prev)
 		prev = i
-		egs.Write([]int{delta})
 	}
 	egs.Close()
 
 	return bytestream.Bytes()
 }
 
+// Deltas returns the residuals DeltaEncode would code for data:
+// each value minus its predecessor, with start preceding the first.
+// Arithmetic wraps like any Go int, and Integrate wraps back the
+// same way, so the pair always round-trips.
+func Deltas(start int, data []int) []int {
+	res := make([]int, len(data))
+	prev := start
+	for i, v := range data {
+		res[i] = v - prev
+		prev = v
+	}
+	return res
+}
+
+// Integrate is the inverse of Deltas:  a running sum of deltas
+// starting from base.
+func Integrate(base int, deltas []int) []int {
+	res := make([]int, len(deltas))
+	val := base
+	for i, d := range deltas {
+		val += d
+		res[i] = val
+	}
+	return res
+}
+
 // Decodes an array of bytes representing an Exp-Golomb encoded
 // stream of residuals of delta compression.  Returns the
-// results as an array of integers.
+// results as an array of integers.  The result is never nil; empty
+// or nil input decodes to an empty slice.
 func DeltaDecode(base int, compressed []byte) []int {
 	res := make([]int, 0)
 	val := base
 	decoder := NewExpGolombDecoder(bytes.NewBuffer(compressed))
 
+	// Decode a chunk at a time to avoid re-entering Read per value.
+	tmp := make([]int, 256)
+	for {
+		n, err := decoder.Read(tmp)
+		for _, d := range tmp[:n] {
+			val = val + d
+			res = append(res, val)
+		}
+		if err != nil {
+			return res
+		}
+	}
+}
+
+// DecodeResiduals decodes the residuals of a DeltaEncode stream
+// without applying a base:  the inverse of Deltas followed by
+// encoding.  ApplyBase turns the result into absolute values.  The
+// result is never nil.
+func DecodeResiduals(compressed []byte) []int {
+	// Residuals are coded just as absolute values are.
+	return DecodeAbsolute(compressed)
+}
+
+// ApplyBase turns residuals read straight from an ExpGolombDecoder
+// into absolute values, so that a stream can be decoded before its
+// base is known and then rebased as often as needed.  It is
+// Integrate with the arguments in decoding order.
+func ApplyBase(residuals []int, base int) []int {
+	return Integrate(base, residuals)
+}
+
+// DecodeLimited is DeltaDecode for untrusted input.  Every '1' bit
+// can be a zero residual, so a short blob may expand enormously;
+// DecodeLimited returns the first maxValues values and
+// ErrTooManyValues if the stream holds more than that.
+func DecodeLimited(base int, compressed []byte, maxValues int) ([]int, error) {
+	res := make([]int, 0)
+	val := base
+	decoder := NewExpGolombDecoder(bytes.NewBuffer(compressed))
+
 	tmp := make([]int, 1)
 	for {
 		n, err := decoder.Read(tmp)
 		if n > 0 {
+			if len(res) == maxValues {
+				return res, ErrTooManyValues
+			}
 			val = val + tmp[0]
 			res = append(res, val)
 		}
-		if err != nil {
-			return res
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
 		}
 	}
-	return res // NOTREACHED - compiler doesn't know it.
+}
+
+// DecodeExact is DeltaDecode for a stream known to hold exactly
+// expected values.  Fewer or more gives ErrCountMismatch, along with
+// at most the first expected values; a partial codeword after them
+// gives io.ErrUnexpectedEOF.
+func DecodeExact(base int, compressed []byte, expected int) ([]int, error) {
+	if expected < 0 {
+		return make([]int, 0), ErrCountMismatch
+	}
+	// Every codeword takes at least a bit.
+	size := expected
+	if size > 8*len(compressed) {
+		size = 8 * len(compressed)
+	}
+	res := make([]int, size)
+	decoder := NewExpGolombDecoder(bytes.NewReader(compressed))
+	n, err := decoder.Read(res)
+	res = Integrate(base, res[:n])
+	if err != nil && err != io.EOF {
+		return res, err
+	}
+	if n < expected {
+		return res, ErrCountMismatch
+	}
+	if m, _ := decoder.Read(make([]int, 1)); m > 0 {
+		return res, ErrCountMismatch
+	}
+	if decoder.truncated() {
+		return res, io.ErrUnexpectedEOF
+	}
+	return res, nil
 }
@@ -1,10 +1,18 @@
 package deltagolomb
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
+	"io"
 	"io/ioutil"
+	"math"
+	"math/bits"
 	"math/rand"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 type etest struct {
@@ -35,6 +43,218 @@ func TestEncode(t *testing.T) {
 	}
 }
 
+// Every value must encode identically whether or not add() takes
+// one of its small-value shortcuts.
+func TestFastPathMatchesGeneral(t *testing.T) {
+	for i := -70000; i <= 70000; i++ {
+		fast := &bytes.Buffer{}
+		general := &bytes.Buffer{}
+		fe := NewExpGolombEncoder(fast)
+		ge := NewExpGolombEncoder(general)
+		// A leading 3-bit value shifts the codeword off byte alignment.
+		for _, e := range []*ExpGolombEncoder{fe, ge} {
+			e.addBits(0x5, 3)
+		}
+		fe.add(i)
+		ge.addGeneral(i)
+		fe.Close()
+		ge.Close()
+		if bytes.Compare(fast.Bytes(), general.Bytes()) != 0 {
+			t.Fatalf("Value %d: fast path gave %v, general path %v",
+				i, fast.Bytes(), general.Bytes())
+		}
+	}
+}
+
+func TestUseFastPath(t *testing.T) {
+	vals := make([]int, 0)
+	for i := -2000; i <= 2000; i++ {
+		vals = append(vals, i)
+	}
+	for i := 0; i < 2000; i++ {
+		vals = append(vals, rand.Int()-rand.Int(), rand.Intn(33)-16)
+	}
+	vals = append(vals, -1<<63, 1<<63-1)
+
+	fast := &bytes.Buffer{}
+	slow := &bytes.Buffer{}
+	fe := NewExpGolombEncoder(fast)
+	se := NewExpGolombEncoder(slow)
+	se.UseFastPath(false)
+	fe.Write(vals)
+	se.Write(vals)
+	fe.Close()
+	se.Close()
+	if !bytes.Equal(fast.Bytes(), slow.Bytes()) {
+		t.Fatal("Output depends on UseFastPath")
+	}
+}
+
+func TestSmallCodes(t *testing.T) {
+	for v := -smallCodeMax; v <= smallCodeMax; v++ {
+		c := smallCodes[v+smallCodeMax]
+		if int(c.nbits) != codeLen(v) {
+			t.Errorf("Value %d: table has %d bits, want %d", v, c.nbits, codeLen(v))
+		}
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoder(buf)
+		egs.WriteInt(v)
+		egs.Close()
+		res := make([]int, 2)
+		if n, _ := NewExpGolombDecoder(buf).Read(res); n != 1 || res[0] != v {
+			t.Errorf("Value %d decoded as %v", v, res[:n])
+		}
+	}
+}
+
+func TestBitLen(t *testing.T) {
+	ref := func(x uint) (n int) {
+		for ; x != 0; x >>= 1 {
+			n++
+		}
+		return
+	}
+	check := func(x uint) {
+		if bitLen(x) != ref(x) {
+			t.Fatalf("bitLen(%d) = %d, want %d", x, bitLen(x), ref(x))
+		}
+	}
+	for _, x := range []uint{0, 1, 2, 3, 1<<31 - 1, 1 << 31, 1<<31 + 1} {
+		check(x)
+	}
+	for shift := uint(1); shift < 64; shift++ {
+		check(1<<shift - 1)
+		check(1 << shift)
+		check(1<<shift + 1)
+	}
+}
+
+// Values whose item+1 lands exactly on a power of two at or past 2^31.
+var bitLenBoundary = []int{1<<31 - 1, -(1<<31 - 1), 1<<32 - 1, 1<<62 - 1, -(1<<62 - 1), 1<<63 - 1}
+
+func TestEncodeDecodeBitLenBoundary(t *testing.T) {
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	encoder.Write(bitLenBoundary)
+	encoder.Close()
+	res := make([]int, len(bitLenBoundary))
+	n, _ := NewExpGolombDecoder(buf).Read(res)
+	if n != len(bitLenBoundary) {
+		t.Fatalf("Expected %d results, got %d", len(bitLenBoundary), n)
+	}
+	for i, exp := range bitLenBoundary {
+		if res[i] != exp {
+			t.Fatalf("item %d was %d, expected %d", i, res[i], exp)
+		}
+	}
+}
+
+// Codewords of 66 bits and more, up to the 128 bits of math.MinInt,
+// at every alignment within a word.
+func TestEncodeDecodeWideCodewords(t *testing.T) {
+	wide := []int{1 << 32, -(1 << 32), 1<<62 - 1, 1 << 62, 1<<63 - 1, -(1<<63 - 1), -1 << 63}
+	for shift := 0; shift < egWordBits; shift++ {
+		buf := &bytes.Buffer{}
+		encoder := NewExpGolombEncoder(buf)
+		for i := 0; i < shift; i++ {
+			encoder.WriteInt(0)
+		}
+		encoder.Write(wide)
+		encoder.Close()
+
+		res := make([]int, shift+len(wide))
+		n, _ := NewExpGolombDecoder(buf).Read(res)
+		if n != len(res) {
+			t.Fatalf("Shift %d: got %d values, want %d", shift, n, len(res))
+		}
+		for i, exp := range wide {
+			if res[shift+i] != exp {
+				t.Fatalf("Shift %d: item %d was %d, expected %d", shift, i, res[shift+i], exp)
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	encoder.WriteUnsigned(^uint(0))
+	encoder.WriteUnsigned(7)
+	encoder.Close()
+	ures := make([]uint, 2)
+	if n, _ := NewExpGolombDecoder(buf).ReadUnsigned(ures); n != 2 || ures[0] != ^uint(0) || ures[1] != 7 {
+		t.Fatalf("Largest unsigned value decoded to %v", ures[:n])
+	}
+}
+
+func TestDecodeOverflow(t *testing.T) {
+	// codeword writes the prefix of zeros, a one, the low bits of
+	// suffix below it and, unless sign is negative, the sign bit.
+	// The magnitude is 2^zeros + suffix - 1.
+	codeword := func(zeros uint, suffix uint64, sign int) []byte {
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoder(buf)
+		egs.WriteRawBits(0, zeros)
+		egs.WriteRawBits(1, 1)
+		egs.WriteRawBits(suffix, zeros)
+		if sign >= 0 {
+			egs.WriteRawBits(uint64(sign), 1)
+		}
+		egs.Close()
+		return buf.Bytes()
+	}
+	bad := [][]byte{
+		codeword(64, 0, 0),       // 2^64 - 1
+		codeword(64, 0, 1),       // -(2^64 - 1)
+		codeword(63, 1, 0),       // 2^63
+		codeword(63, 1<<63-1, 1), // -(2^64 - 2)
+		codeword(66, 0, 0),       // 65 zeros would be a terminator
+	}
+	for i, stream := range bad {
+		if _, err := NewExpGolombDecoder(bytes.NewReader(stream)).Read(make([]int, 1)); err != ErrOverflow {
+			t.Errorf("Codeword %d: expected ErrOverflow, got %v", i, err)
+		}
+	}
+	// 2^64 and 2^66 - 1.
+	for i, stream := range [][]byte{codeword(64, 1, -1), codeword(66, 0, -1)} {
+		if _, err := NewExpGolombDecoder(bytes.NewReader(stream)).ReadUnsigned(make([]uint, 1)); err != ErrOverflow {
+			t.Errorf("Unsigned codeword %d: expected ErrOverflow, got %v", i, err)
+		}
+	}
+
+	// The largest magnitudes that fit still decode.
+	res := make([]int, 2)
+	stream := append(codeword(63, 1, 1), codeword(63, 0, 0)...)
+	if n, err := NewExpGolombDecoder(bytes.NewReader(stream)).Read(res); n != 2 || res[0] != -1<<63 || res[1] != 1<<63-1 {
+		t.Fatalf("Got %v, %v", res[:n], err)
+	}
+}
+
+// addBits treats nbits beyond the word size as leading zeros.
+func TestAddBitsWide(t *testing.T) {
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	encoder.addBits(0x3, 3)
+	encoder.addBits(0x5, 70)
+	encoder.Close()
+	want := []byte{0x60, 0, 0, 0, 0, 0, 0, 0, 0x02, 0x80}
+	if bytes.Compare(buf.Bytes(), want) != 0 {
+		t.Fatalf("Got %v, want %v", buf.Bytes(), want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, bt := range bytetests {
+		d := DeltaDecode(0, bt.bytes)
+		if len(d) != len(bt.ints) {
+			t.Fatal("Decode of ", bt.bytes, " failed, got ", d, " expected ", bt.ints)
+		}
+		for i := range d {
+			if d[i] != bt.ints[i] {
+				t.Fatal("Decode of ", bt.bytes, " failed, got ", d, " expected ", bt.ints)
+			}
+		}
+	}
+}
+
 var cornertests []int = []int{2147483646, -2147483646}
 
 func TestEncodeDecode(t *testing.T) {
@@ -92,6 +312,196 @@ func TestEncodeDecodeRandom(t *testing.T) {
 	}
 }
 
+func TestUnsignedEncodeDecode(t *testing.T) {
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	decoder := NewExpGolombDecoder(buf)
+	n_exhaustive := 65537
+	for i := 0; i < n_exhaustive; i++ {
+		encoder.WriteUnsigned(uint(i))
+	}
+	encoder.Close()
+
+	res := make([]uint, n_exhaustive)
+	n, _ := decoder.ReadUnsigned(res)
+	if n != n_exhaustive {
+		t.Fatalf("Not enough results.  Expected %d, got %d\n", n_exhaustive, n)
+	}
+	for i := 0; i < n_exhaustive; i++ {
+		if res[i] != uint(i) {
+			t.Fatalf("item %d was %d, expected %d\n", i, res[i], i)
+		}
+	}
+}
+
+func TestFeed(t *testing.T) {
+	vals := make([]int, 2000)
+	for i := range vals {
+		vals[i] = rand.Intn(100000) - 50000
+	}
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	encoder.Write(vals)
+	encoder.Close()
+	stream := buf.Bytes()
+
+	check := func(name string, res []int) {
+		if len(res) != len(vals) {
+			t.Fatalf("%s: got %d values, want %d", name, len(res), len(vals))
+		}
+		for i := range vals {
+			if res[i] != vals[i] {
+				t.Fatalf("%s: item %d was %d, expected %d", name, i, res[i], vals[i])
+			}
+		}
+	}
+
+	decoder := NewExpGolombDecoder(nil)
+	res := make([]int, 0)
+	for i := range stream {
+		res = append(res, decoder.Feed(stream[i:i+1])...)
+	}
+	check("byte at a time", res)
+
+	decoder = NewExpGolombDecoder(nil)
+	res = make([]int, 0)
+	for pos := 0; pos < len(stream); {
+		end := pos + rand.Intn(17)
+		if end > len(stream) {
+			end = len(stream)
+		}
+		res = append(res, decoder.Feed(stream[pos:end])...)
+		pos = end
+	}
+	check("random chunks", res)
+	if decoder.Err() != nil {
+		t.Fatal("Err after a clean stream: ", decoder.Err())
+	}
+
+	// A value of 1, then a 66-zero prefix split across two chunks.
+	decoder = NewExpGolombDecoder(nil)
+	res = decoder.Feed([]byte{0x40, 0, 0, 0})
+	res = append(res, decoder.Feed([]byte{0, 0, 0, 0, 0x02, 0x80})...)
+	if len(res) != 1 || res[0] != 1 || decoder.Err() != ErrOverflow {
+		t.Fatalf("Overflowing stream gave %v, %v", res, decoder.Err())
+	}
+	if more := decoder.Feed([]byte{0x80}); len(more) != 0 || decoder.Err() != ErrOverflow {
+		t.Fatalf("Feed after an error gave %v, %v", more, decoder.Err())
+	}
+}
+
+func TestDecoderStats(t *testing.T) {
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	// Lengths: 1, 1, 4, 4, 4, 6, 6, 8.
+	encoder.Write([]int{0, 0, 1, -1, 2, 3, -6, 7})
+	encoder.Close()
+	decoder := NewExpGolombDecoder(buf)
+	if decoder.DecoderStats() != nil {
+		t.Fatal("Stats should be nil until enabled")
+	}
+	decoder.EnableStats()
+	res := make([]int, 8)
+	decoder.Read(res)
+	want := []int{0, 2, 0, 0, 3, 0, 2, 0, 1}
+	got := decoder.DecoderStats()
+	if len(got) != len(want) {
+		t.Fatalf("Got histogram %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Got histogram %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMaxBitsDetectsBitOrder(t *testing.T) {
+	vals := []int{1 << 20, 1 << 20, 1 << 20, 1 << 20}
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	encoder.Write(vals)
+	encoder.Close()
+	msb := buf.Bytes()
+	lsb := make([]byte, len(msb))
+	for i, b := range msb {
+		lsb[i] = bits.Reverse8(b)
+	}
+
+	res := make([]int, len(vals))
+	decoder := NewExpGolombDecoder(bytes.NewReader(msb))
+	decoder.SetMaxBits(21)
+	if n, err := decoder.Read(res); n != len(vals) || (err != nil && err != io.EOF) {
+		t.Fatalf("MSB stream: got (%d, %v), want all %d values", n, err, len(vals))
+	}
+
+	decoder = NewExpGolombDecoder(bytes.NewReader(lsb))
+	decoder.SetMaxBits(21)
+	if _, err := decoder.Read(res); err != ErrBitOrder {
+		t.Fatal("LSB stream: expected ErrBitOrder, got ", err)
+	}
+}
+
+func TestDeltaDecodeChunked(t *testing.T) {
+	data := make([]int, 1000)
+	for i := range data {
+		data[i] = rand.Intn(2001) - 1000
+	}
+	compressed := DeltaEncode(3, data)
+	got := DeltaDecode(3, compressed)
+	want := deltaDecodeOneAtATime(3, compressed)
+	if len(got) != len(want) {
+		t.Fatalf("Got %d values, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("item %d was %d, expected %d", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDecodeResiduals(t *testing.T) {
+	data := []int{10, 12, 9, 9, 1000, -4}
+	res := DecodeResiduals(DeltaEncode(7, data))
+	want := Deltas(7, data)
+	if len(res) != len(want) {
+		t.Fatalf("Got %d residuals, want %d", len(res), len(want))
+	}
+	for i := range want {
+		if res[i] != want[i] {
+			t.Fatalf("Residual %d was %d, expected %d", i, res[i], want[i])
+		}
+	}
+	vals := ApplyBase(res, 7)
+	for i := range data {
+		if vals[i] != data[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, vals[i], data[i])
+		}
+	}
+	if res := DecodeResiduals(nil); res == nil || len(res) != 0 {
+		t.Fatal("Expected empty non-nil slice, got ", res)
+	}
+}
+
+func TestApplyBase(t *testing.T) {
+	data := []int{10, 12, 9, 9, 400, -3}
+	compressed := DeltaEncode(5, data)
+
+	residuals := make([]int, len(data))
+	n, _ := NewExpGolombDecoder(bytes.NewReader(compressed)).Read(residuals)
+	if n != len(data) {
+		t.Fatalf("Got %d residuals, want %d", n, len(data))
+	}
+	for _, base := range []int{5, 0, -1000} {
+		want := DeltaDecode(base, compressed)
+		got := ApplyBase(residuals, base)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("Base %d: item %d was %d, expected %d", base, i, got[i], want[i])
+			}
+		}
+	}
+}
+
 func TestDeltaEncodeDecode(t *testing.T) {
 	o := make([]int, 25)
 	base := 6329
@@ -112,45 +522,1394 @@ func TestDeltaEncodeDecode(t *testing.T) {
 	}
 }
 
-var benchvals = []int{0, 1, -1, 2, -5}
+func TestMakeWriterNoBufio(t *testing.T) {
+	if _, ok := makeWriter(&bytes.Buffer{}, 0).(*bufio.Writer); ok {
+		t.Fatal("bytes.Buffer should not be wrapped in a bufio.Writer")
+	}
+	if _, ok := makeWriter(ioutil.Discard, 0).(*bufio.Writer); !ok {
+		t.Fatal("Plain io.Writer should be wrapped in a bufio.Writer")
+	}
+}
 
-func BenchmarkExpGEncode(b *testing.B) {
-	b.StopTimer()
+// byteSink has WriteByte but no Flush, and counts every call.
+type byteSink struct {
+	data   []byte
+	writes int
+}
 
-	egs := NewExpGolombEncoder(ioutil.Discard)
-	b.StartTimer()
-	for i := 0; i < b.N; i++ {
-		egs.Write(benchvals)
+func (s *byteSink) Write(p []byte) (int, error) {
+	s.writes++
+	s.data = append(s.data, p...)
+	return len(p), nil
+}
+
+func (s *byteSink) WriteByte(c byte) error {
+	s.writes++
+	s.data = append(s.data, c)
+	return nil
+}
+
+func TestByteSinkUsedDirectly(t *testing.T) {
+	sink := &byteSink{}
+	if _, ok := makeWriter(sink, 0).(*bufio.Writer); ok {
+		t.Fatal("A sink with WriteByte should not be wrapped in a bufio.Writer")
+	}
+	egs := NewExpGolombEncoder(sink)
+	egs.Write(make([]int, 64)) // 64 one-bit zeros fill a word
+	if sink.writes != 1 || len(sink.data) != 8 {
+		t.Fatalf("Sink saw %d writes of %d bytes before Close", sink.writes, len(sink.data))
+	}
+	egs.WriteInt(1)
+	if err := egs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sink.writes != 2 || len(sink.data) != 9 || sink.data[8] != 0x40 {
+		t.Fatalf("Sink saw %d writes, data %x", sink.writes, sink.data)
+	}
+}
+
+func TestReadFull(t *testing.T) {
+	vals := []int{3, -1, 0, 900, -7}
+	stream := func() *ExpGolombDecoder {
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoder(buf)
+		egs.Write(vals)
+		egs.Close()
+		return NewExpGolombDecoder(buf)
+	}
+
+	// Exact.
+	out := make([]int, len(vals))
+	if err := stream().ReadFull(out); err != nil {
+		t.Fatal("Exact ReadFull failed: ", err)
+	}
+	for i := range vals {
+		if out[i] != vals[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, out[i], vals[i])
+		}
+	}
+
+	// Longer stream than out; the rest stays readable.
+	d := stream()
+	out = make([]int, 2)
+	if err := d.ReadFull(out); err != nil || out[0] != 3 || out[1] != -1 {
+		t.Fatalf("Partial ReadFull gave %v, %v", out, err)
+	}
+	out = make([]int, 3)
+	if err := d.ReadFull(out); err != nil || out[2] != -7 {
+		t.Fatalf("Second ReadFull gave %v, %v", out, err)
+	}
+
+	// Stream shorter than out.
+	out = make([]int, len(vals)+1)
+	if err := stream().ReadFull(out); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+
+	if err := stream().ReadFull(nil); err != nil {
+		t.Fatal("Empty ReadFull failed: ", err)
+	}
+}
+
+func TestReadInt32(t *testing.T) {
+	vals := []int{0, -5, math.MaxInt32, math.MinInt32, 77, math.MaxInt32 + 1, 3}
+	for _, mode := range []SignMode{SignBit, ZigZag} {
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoderMode(buf, mode)
+		egs.Write(vals)
+		egs.Close()
+		d, err := NewExpGolombDecoderAuto(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := make([]int32, len(vals))
+		out[5] = -1
+		n, err := d.ReadInt32(out)
+		if err != ErrOverflow || n != 5 {
+			t.Fatalf("Mode %d: got %d values and %v, want 5 and ErrOverflow", mode, n, err)
+		}
+		if out[5] != -1 {
+			t.Fatalf("Mode %d: overflowing value stored as %d", mode, out[5])
+		}
+		for i := 0; i < n; i++ {
+			if int(out[i]) != vals[i] {
+				t.Fatalf("Mode %d: item %d was %d, expected %d", mode, i, out[i], vals[i])
+			}
+		}
+		// The overflowing value is skipped; reading resumes after it.
+		n, err = d.ReadInt32(out[:1])
+		if n != 1 || out[0] != 3 {
+			t.Fatalf("Mode %d: after overflow got %d values, %v, %v", mode, n, out[:n], err)
+		}
+		if n, err = d.ReadInt32(out); n != 0 || err != io.EOF {
+			t.Fatalf("Mode %d: expected io.EOF, got %d, %v", mode, n, err)
+		}
+	}
+}
+
+func TestReadRawBits(t *testing.T) {
+	for _, width := range []uint{0, 1, 3, 8, 13, 63, 64} {
+		header := uint64(0x9e3779b97f4a7c15)
+		if width < 64 {
+			header &= 1<<width - 1
+		}
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoder(buf)
+		egs.WriteRawBits(header, width)
+		egs.Write([]int{5, -1, 0, 300})
+		egs.Close()
+
+		d := NewExpGolombDecoder(buf)
+		got, err := d.ReadRawBits(width)
+		if err != nil || got != header {
+			t.Fatalf("Width %d: read %x, %v, want %x", width, got, err, header)
+		}
+		out := make([]int, 4)
+		if err := d.ReadFull(out); err != nil || out[0] != 5 || out[3] != 300 {
+			t.Fatalf("Width %d: values after header were %v, %v", width, out, err)
+		}
+	}
+
+	d := NewExpGolombDecoder(bytes.NewReader([]byte{0xff}))
+	if _, err := d.ReadRawBits(65); err != ErrRawWidth {
+		t.Fatal("Expected ErrRawWidth, got ", err)
+	}
+	if _, err := d.ReadRawBits(12); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+	if _, err := d.ReadRawBits(1); err != io.EOF {
+		t.Fatal("Expected io.EOF, got ", err)
+	}
+}
+
+func TestWriteRawBits(t *testing.T) {
+	type field struct {
+		bits  uint64
+		n     uint
+		value int
+	}
+	fields := []field{
+		{0x5, 3, -2}, {0xffffffffffffffff, 64, 1 << 40}, {0x1ff, 4, 0},
+		{0, 0, 7}, {0x123456789, 36, -1}, {1, 70, 3}, {0x80, 8, -300},
+	}
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	// Start off byte and word alignment.
+	egs.WriteInt(1)
+	for _, f := range fields {
+		egs.WriteRawBits(f.bits, f.n)
+		egs.WriteInt(f.value)
 	}
 	egs.Close()
+
+	d := NewExpGolombDecoder(buf)
+	out := make([]int, 1)
+	if err := d.ReadFull(out); err != nil || out[0] != 1 {
+		t.Fatalf("First value was %v, %v", out, err)
+	}
+	for i, f := range fields {
+		n := f.n
+		if n > 64 {
+			if zeros, err := d.ReadRawBits(n - 64); err != nil || zeros != 0 {
+				t.Fatalf("Field %d: leading bits were %x, %v", i, zeros, err)
+			}
+			n = 64
+		}
+		want := f.bits
+		if n < 64 {
+			want &= 1<<n - 1
+		}
+		if got, err := d.ReadRawBits(n); err != nil || got != want {
+			t.Fatalf("Field %d: read %x, %v, want %x", i, got, err, want)
+		}
+		if err := d.ReadFull(out); err != nil || out[0] != f.value {
+			t.Fatalf("Field %d: value was %v, %v, want %d", i, out, err, f.value)
+		}
+	}
 }
 
-// Benchmarks decode speed.  Because it resets the buffer
-// and does some other work, this test decodes 200 symbols
-// per iteration, so divde the ns/op by 200 to find
-// the per-symbol cost.
-func BenchmarkExpGDecode(b *testing.B) {
-	b.StopTimer()
+func TestUnary(t *testing.T) {
 	buf := &bytes.Buffer{}
 	egs := NewExpGolombEncoder(buf)
-	for i := 0; i < 40; i++ {
-		egs.Write(benchvals)
+	for n := uint(0); n <= 100; n++ {
+		egs.WriteUnary(n)
+		egs.WriteInt(-int(n))
 	}
 	egs.Close()
 
-	bbytes := buf.Bytes()
-	saved_b := make([]byte, len(bbytes))
-	copy(saved_b, bbytes)
+	d := NewExpGolombDecoder(buf)
+	tmp := make([]int, 1)
+	for n := uint(0); n <= 100; n++ {
+		u, err := d.ReadUnary()
+		if err != nil || u != n {
+			t.Fatalf("Unary %d read as %d, %v", n, u, err)
+		}
+		if err := d.ReadFull(tmp); err != nil || tmp[0] != -int(n) {
+			t.Fatalf("Value after unary %d was %d, %v", n, tmp[0], err)
+		}
+	}
 
-	b.StartTimer()
-	res := make([]int, 200)
-	for i := 0; i < b.N; i++ {
-		decoder := NewExpGolombDecoder(buf)
-		n, _ := decoder.Read(res)
-		if n != 200 {
-			b.Fatalf("Expected 200 ints, got %d", n)
+	// A unary count is an unsigned codeword's prefix.
+	buf.Reset()
+	egs = NewExpGolombEncoder(buf)
+	egs.WriteUnary(2)
+	egs.Close()
+	if !bytes.Equal(buf.Bytes(), []byte{0x20}) {
+		t.Fatalf("Unary 2 encoded as %x", buf.Bytes())
+	}
+	if _, err := NewExpGolombDecoder(bytes.NewReader(nil)).ReadUnary(); err != io.EOF {
+		t.Fatal("Expected io.EOF, got ", err)
+	}
+	if _, err := NewExpGolombDecoder(bytes.NewReader([]byte{0x00})).ReadUnary(); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+	// Past the last count only the five padding zeros are left.
+	d = NewExpGolombDecoder(bytes.NewReader([]byte{0x20}))
+	if u, err := d.ReadUnary(); u != 2 || err != nil {
+		t.Fatalf("Unary 2 read as %d, %v", u, err)
+	}
+	if _, err := d.ReadUnary(); err != io.EOF {
+		t.Fatal("Expected io.EOF at the padding, got ", err)
+	}
+}
+
+func TestBytesConsumed(t *testing.T) {
+	trailer := []byte{0xde, 0xad, 0xbe, 0xef}
+	// 0 is one bit and 3 is six, so these sets end mid-byte, on a
+	// byte boundary, and after several bytes.
+	for _, vals := range [][]int{{0}, {0, 0, 0, 0, 0, 0, 0, 0}, {3, 0, 0}, {3, -3, 0, 100, 1 << 40}} {
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoder(buf)
+		egs.Write(vals)
+		egs.Close()
+		size := buf.Len()
+		buf.Write(trailer)
+
+		d := NewExpGolombDecoder(buf)
+		out := make([]int, len(vals))
+		if err := d.ReadFull(out); err != nil {
+			t.Fatal(err)
 		}
-		buf.Reset()
-		buf.Write(saved_b)
+		if d.BytesConsumed() != size {
+			t.Fatalf("Values %v: consumed %d bytes, want %d", vals, d.BytesConsumed(), size)
+		}
+		if !bytes.Equal(buf.Bytes(), trailer) {
+			t.Fatalf("Values %v: reader left at %x", vals, buf.Bytes())
+		}
+	}
+
+	d := NewExpGolombDecoder(bytes.NewReader([]byte{0x21, 0x1c}))
+	if n, _ := d.Read(make([]int, 10)); n != 3 || d.BytesConsumed() != 2 {
+		t.Fatalf("Got %d values from %d bytes", n, d.BytesConsumed())
+	}
+}
+
+// countingByteReader offers nothing but ReadByte.
+type countingByteReader struct {
+	data  []byte
+	calls int
+}
+
+func (r *countingByteReader) ReadByte() (byte, error) {
+	r.calls++
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b, nil
+}
+
+func TestDecoderByteReader(t *testing.T) {
+	vals := []int{3, -3, 0, 100, 1 << 40}
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	egs.Write(vals)
+	egs.Close()
+	size := buf.Len()
+
+	for _, r := range []io.Reader{bytes.NewReader(nil), bufio.NewReader(nil), buf} {
+		if d := NewExpGolombDecoder(r); d.r != r.(io.ByteReader) {
+			t.Fatalf("%T was wrapped in %T", r, d.r)
+		}
+	}
+
+	cr := &countingByteReader{data: buf.Bytes()}
+	d := NewExpGolombDecoderByteReader(cr)
+	if d.r != io.ByteReader(cr) {
+		t.Fatalf("ByteReader was wrapped in %T", d.r)
+	}
+	out := make([]int, 10)
+	n, err := d.Read(out)
+	if err != io.EOF || n != len(vals) {
+		t.Fatalf("Got %d values, err %v", n, err)
+	}
+	for i, v := range vals {
+		if out[i] != v {
+			t.Fatalf("Item %d was %d, expected %d", i, out[i], v)
+		}
+	}
+	// One call per byte plus the one that reported io.EOF.
+	if cr.calls != size+1 {
+		t.Fatalf("ReadByte called %d times for %d bytes", cr.calls, size)
+	}
+}
+
+func TestDecodeDataWithEOF(t *testing.T) {
+	// 1<<40 ends in the last byte, after a long run of whole bytes.
+	vals := []int{3, -3, 0, 100, 1 << 40}
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	egs.Write(vals)
+	egs.Close()
+
+	// Readers that hand over their final bytes together with io.EOF,
+	// all at once or a byte at a time.
+	for _, r := range []io.Reader{
+		iotest.DataErrReader(bytes.NewReader(buf.Bytes())),
+		iotest.DataErrReader(iotest.OneByteReader(bytes.NewReader(buf.Bytes()))),
+	} {
+		out := make([]int, 10)
+		n, err := NewExpGolombDecoder(r).Read(out)
+		if n != len(vals) || err != io.EOF {
+			t.Fatalf("Got %d values, err %v", n, err)
+		}
+		for i, v := range vals {
+			if out[i] != v {
+				t.Fatalf("Item %d was %d, expected %d", i, out[i], v)
+			}
+		}
+	}
+}
+
+func TestCheckPadding(t *testing.T) {
+	check := func(stream []byte, count int) error {
+		d := NewExpGolombDecoder(bytes.NewReader(stream))
+		if err := d.ReadFull(make([]int, count)); err != nil {
+			return err
+		}
+		return d.CheckPadding()
+	}
+	// 3 then -3, twelve bits, with the last four bits padding.
+	if err := check([]byte{0x20, 0x90}, 2); err != nil {
+		t.Fatal("Clean padding: ", err)
+	}
+	for _, dirty := range [][]byte{{0x20, 0x91}, {0x20, 0x98}} {
+		if err := check(dirty, 2); err != ErrPadding {
+			t.Fatalf("Padding of %x: expected ErrPadding, got %v", dirty, err)
+		}
+	}
+	// 3, 0, 0 fill a byte exactly; then there is nothing or too much.
+	if err := check([]byte{0x23}, 3); err != nil {
+		t.Fatal("Byte boundary: ", err)
+	}
+	if err := check([]byte{0x23, 0x00}, 3); err != ErrPadding {
+		t.Fatal("Extra byte: expected ErrPadding, got ", err)
+	}
+	if err := check([]byte{0x23}, 2); err != ErrPadding {
+		t.Fatal("Value left over: expected ErrPadding, got ", err)
+	}
+}
+
+func TestDeltasIntegrate(t *testing.T) {
+	data := []int{3, 3, -10, 1 << 40, -1 << 63, 1<<63 - 1, 0}
+	base := 5
+	deltas := Deltas(base, data)
+	if deltas[0] != -2 || deltas[1] != 0 || deltas[2] != -13 {
+		t.Fatalf("Unexpected deltas %v", deltas)
+	}
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	encoder.Write(deltas)
+	encoder.Close()
+	if bytes.Compare(buf.Bytes(), DeltaEncode(base, data)) != 0 {
+		t.Fatal("Deltas does not match the residuals DeltaEncode codes")
+	}
+	res := Integrate(base, deltas)
+	for i := range data {
+		if res[i] != data[i] {
+			t.Fatalf("item %d was %d, expected %d", i, res[i], data[i])
+		}
+	}
+	if len(Deltas(base, nil)) != 0 || len(Integrate(base, nil)) != 0 {
+		t.Fatal("Expected empty results for empty input")
+	}
+}
+
+// Every entry point must behave predictably when handed nothing.
+func TestEmptyInput(t *testing.T) {
+	if e := DeltaEncode(3, nil); len(e) != 0 {
+		t.Fatal("DeltaEncode(nil) should produce no bytes, got ", e)
+	}
+	for _, in := range [][]byte{nil, {}} {
+		if d := DeltaDecode(3, in); d == nil || len(d) != 0 {
+			t.Fatalf("DeltaDecode(%v) = %#v, want empty non-nil slice", in, d)
+		}
+	}
+
+	decoder := NewExpGolombDecoder(&bytes.Buffer{})
+	if n, err := decoder.Read(make([]int, 0)); n != 0 || err != nil {
+		t.Fatalf("Read into empty slice = (%d, %v), want (0, nil)", n, err)
+	}
+	if n, err := decoder.Read(make([]int, 4)); n != 0 || err != io.EOF {
+		t.Fatalf("Read of empty stream = (%d, %v), want (0, io.EOF)", n, err)
+	}
+	if n, err := decoder.ReadUnsigned(make([]uint, 4)); n != 0 || err != io.EOF {
+		t.Fatalf("ReadUnsigned of empty stream = (%d, %v), want (0, io.EOF)", n, err)
+	}
+	if d := NewExpGolombDecoder(nil).Feed(nil); d == nil || len(d) != 0 {
+		t.Fatalf("Feed(nil) = %#v, want empty non-nil slice", d)
+	}
+	if _, err := NewExpGolombDecoderAuto(&bytes.Buffer{}); err != io.EOF {
+		t.Fatal("NewExpGolombDecoderAuto on empty stream should return io.EOF, got ", err)
+	}
+
+	if d := Deltas(0, nil); d == nil || len(d) != 0 {
+		t.Fatalf("Deltas(nil) = %#v, want empty non-nil slice", d)
+	}
+	if d := Integrate(0, nil); d == nil || len(d) != 0 {
+		t.Fatalf("Integrate(nil) = %#v, want empty non-nil slice", d)
+	}
+
+	if n, err := VarintReader(0, nil).Read(make([]byte, 8)); n != 0 || err != io.EOF {
+		t.Fatalf("VarintReader(nil).Read = (%d, %v), want (0, io.EOF)", n, err)
+	}
+	if _, ok, err := NewBufferedDecoder(&bytes.Buffer{}, 0, 4).Next(); ok || err != nil {
+		t.Fatalf("BufferedDecoder.Next on empty stream = (%v, %v), want (false, nil)", ok, err)
+	}
+
+	buf := &bytes.Buffer{}
+	EncodeBitRuns(buf, nil)
+	if buf.Len() != 0 {
+		t.Fatal("EncodeBitRuns(nil) should produce no bytes")
+	}
+	if d, err := DecodeBitRuns(buf); d == nil || len(d) != 0 || err != nil {
+		t.Fatalf("DecodeBitRuns of empty stream = (%#v, %v)", d, err)
+	}
+
+	// An empty column set still has a header, so an empty stream is
+	// truncated rather than empty.
+	EncodeColumns(buf, nil, nil)
+	if c, err := DecodeColumns(buf, nil); c == nil || len(c) != 0 || err != nil {
+		t.Fatalf("DecodeColumns of zero columns = (%#v, %v)", c, err)
+	}
+	if _, err := DecodeColumns(&bytes.Buffer{}, nil); err != io.ErrUnexpectedEOF {
+		t.Fatal("DecodeColumns of empty stream should return io.ErrUnexpectedEOF, got ", err)
+	}
+
+	a := NewAppender(nil, 0, 5)
+	a.AppendValues(nil)
+	if e := a.Finish(); len(e) != 0 || a.BitLen() != 0 || a.Last() != 5 {
+		t.Fatalf("Empty Appender gave %v, BitLen %d, Last %d", e, a.BitLen(), a.Last())
+	}
+}
+
+var errFailWriter = errors.New("failWriter: out of space")
+
+// failWriter accepts limit bytes and then fails every write.
+type failWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (f *failWriter) Write(p []byte) (int, error) {
+	if f.Len()+len(p) > f.limit {
+		return 0, errFailWriter
+	}
+	return f.Buffer.Write(p)
+}
+
+func (f *failWriter) Flush() error { return nil }
+
+func TestWriteRepeated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	if err := egs.WriteRepeated(5, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := egs.WriteRepeated(-3, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := egs.WriteRepeated(7, -1); err != ErrNegativeCount {
+		t.Fatal("Expected ErrNegativeCount, got ", err)
+	}
+	egs.WriteInt(9)
+	egs.Close()
+	if egs.ValuesWritten() != 101 {
+		t.Fatalf("Wrote %d values, want 101", egs.ValuesWritten())
+	}
+
+	res := make([]int, 102)
+	n, _ := NewExpGolombDecoder(buf).Read(res)
+	if n != 101 || res[100] != 9 {
+		t.Fatalf("Decoded %d values ending %v", n, res[n-1])
+	}
+	for i := 0; i < 100; i++ {
+		if res[i] != -3 {
+			t.Fatalf("Item %d was %d, expected -3", i, res[i])
+		}
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	// Each value is an 82-bit codeword.  With room for one 64-bit
+	// word, the first value's last 18 bits are in the second word,
+	// whose write fails, so nothing is committed.  With room for two
+	// words the first value is safe and the second is cut off.
+	vals := []int{1 << 40, 1 << 40, 1 << 40}
+	for _, c := range []struct{ limit, committed int }{{8, 0}, {16, 1}} {
+		fw := &failWriter{limit: c.limit}
+		encoder := NewExpGolombEncoder(fw)
+		committed, err := encoder.WriteAll(vals)
+		if committed != c.committed || err != errFailWriter {
+			t.Fatalf("Limit %d: WriteAll = (%d, %v), want (%d, %v)", c.limit, committed, err, c.committed, errFailWriter)
+		}
+		// The accepted bytes hold the committed values in full.
+		res := make([]int, len(vals))
+		if n, _ := NewExpGolombDecoder(bytes.NewReader(fw.Bytes())).Read(res); n != committed {
+			t.Fatalf("Limit %d: accepted bytes decode to %d values, want %d", c.limit, n, committed)
+		}
+		if n, err := encoder.WriteAll(vals); n != 0 || err != errFailWriter {
+			t.Fatalf("WriteAll after failure = (%d, %v), want (0, %v)", n, err, errFailWriter)
+		}
+		if err := encoder.Close(); err != errFailWriter {
+			t.Fatal("Close should report the write failure, got ", err)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	if n, err := encoder.WriteAll(vals); n != len(vals) || err != nil {
+		t.Fatalf("WriteAll = (%d, %v), want (%d, nil)", n, err, len(vals))
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatal("Close failed: ", err)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	egs.Write([]int{3, -3, 0})
+	if err := egs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	closed := append([]byte(nil), buf.Bytes()...)
+	if err := egs.Close(); err != nil {
+		t.Fatal("Second Close failed: ", err)
+	}
+
+	// Writes after Close are dropped and reported, not appended.
+	egs.Write([]int{1, 2})
+	egs.WriteInt(1)
+	egs.WriteUnsigned(1)
+	egs.WriteRawBits(1, 1)
+	if err := egs.WriteRepeated(1, 3); err != ErrClosed {
+		t.Fatal("WriteRepeated: expected ErrClosed, got ", err)
+	}
+	if n, err := egs.WriteAll([]int{1}); n != 0 || err != ErrClosed {
+		t.Fatalf("WriteAll = (%d, %v), want (0, ErrClosed)", n, err)
+	}
+	if _, err := egs.Flush(); err != ErrClosed {
+		t.Fatal("Flush: expected ErrClosed, got ", err)
+	}
+	if err := egs.Close(); err != nil {
+		t.Fatal("Close after dropped writes should repeat its first result, got ", err)
+	}
+	if !bytes.Equal(buf.Bytes(), closed) || egs.ValuesWritten() != 3 {
+		t.Fatalf("Stream changed after Close: %x, %d values", buf.Bytes(), egs.ValuesWritten())
+	}
+
+	egs = NewExpGolombEncoder(&failWriter{limit: 0})
+	egs.WriteInt(5)
+	if err := egs.Close(); err != errFailWriter {
+		t.Fatal("Close should report the write failure, got ", err)
+	}
+	if err := egs.Close(); err != errFailWriter {
+		t.Fatal("Second Close should repeat the failure, got ", err)
+	}
+}
+
+func TestZeroValueEncoder(t *testing.T) {
+	for name, use := range map[string]func(*ExpGolombEncoder){
+		"Write": func(e *ExpGolombEncoder) { e.Write([]int{1, 2, 3}) },
+		"Flush": func(e *ExpGolombEncoder) { e.Flush() },
+		"Close": func(e *ExpGolombEncoder) { e.Close() },
+	} {
+		func() {
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.Contains(msg, "NewExpGolombEncoder") {
+					t.Errorf("%s on a zero encoder: panic %q, want one naming NewExpGolombEncoder", name, msg)
+				}
+			}()
+			use(&ExpGolombEncoder{})
+		}()
+	}
+}
+
+func TestTerminator(t *testing.T) {
+	trailer := []byte{0xff, 0xff, 0x00, 0x42}
+	for _, last := range []int{0, 1, -1, 3, 1 << 40, 1<<63 - 1, -1 << 63} {
+		for _, mode := range []SignMode{SignBit, ZigZag} {
+			vals := []int{5, -2, last}
+			buf := &bytes.Buffer{}
+			egs := NewExpGolombEncoderMode(buf, mode)
+			egs.UseTerminator(true)
+			egs.Write(vals)
+			if err := egs.Close(); err != nil {
+				t.Fatal(err)
+			}
+			size := buf.Len()
+			buf.Write(trailer)
+
+			d, err := NewExpGolombDecoderAuto(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := make([]int, 10)
+			n, err := d.Read(out)
+			if n != len(vals) || err != io.EOF {
+				t.Fatalf("Last %d, mode %d: got %d values, err %v", last, mode, n, err)
+			}
+			for i, v := range vals {
+				if out[i] != v {
+					t.Fatalf("Last %d, mode %d: item %d was %d, expected %d", last, mode, i, out[i], v)
+				}
+			}
+			if n, err := d.Read(out); n != 0 || err != io.EOF {
+				t.Fatalf("Read after the terminator = (%d, %v)", n, err)
+			}
+			if d.BytesConsumed() != size || !bytes.Equal(buf.Bytes(), trailer) {
+				t.Fatalf("Last %d, mode %d: consumed %d of %d bytes, left %x", last, mode, d.BytesConsumed(), size, buf.Bytes())
+			}
+		}
+	}
+
+	// A terminator in the middle of a byte, with nothing after it.
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	egs.UseTerminator(true)
+	egs.Close()
+	if res := DeltaDecode(0, buf.Bytes()); len(res) != 0 {
+		t.Fatal("Expected no values, got ", res)
+	}
+}
+
+func TestDecodeLimited(t *testing.T) {
+	// 64 bytes of ones is 512 zero residuals.
+	blob := bytes.Repeat([]byte{0xff}, 64)
+	res, err := DecodeLimited(7, blob, 10)
+	if err != ErrTooManyValues {
+		t.Fatal("Expected ErrTooManyValues, got ", err)
+	}
+	if len(res) != 10 || res[9] != 7 {
+		t.Fatalf("Expected ten sevens, got %v", res)
+	}
+
+	res, err = DecodeLimited(7, blob, 512)
+	if err != nil || len(res) != 512 {
+		t.Fatalf("Exact limit gave %d values and %v", len(res), err)
+	}
+}
+
+func TestDecodeExact(t *testing.T) {
+	data := []int{3, 5, -100, 1 << 40, 7}
+	compressed := DeltaEncode(2, data)
+	res, err := DecodeExact(2, compressed, len(data))
+	if err != nil || len(res) != len(data) {
+		t.Fatalf("Got %v, %v", res, err)
+	}
+	for i := range data {
+		if res[i] != data[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], data[i])
+		}
+	}
+
+	if res, err := DecodeExact(2, compressed, len(data)+1); err != ErrCountMismatch || len(res) != len(data) {
+		t.Fatalf("Short stream gave %d values and %v", len(res), err)
+	}
+	if res, err := DecodeExact(2, compressed, 3); err != ErrCountMismatch || len(res) != 3 || res[2] != -100 {
+		t.Fatalf("Long stream gave %v and %v", res, err)
+	}
+	if _, err := DecodeExact(2, compressed[:len(compressed)-3], len(data)); err != ErrCountMismatch {
+		t.Fatal("Truncated stream: expected ErrCountMismatch, got ", err)
+	}
+	if _, err := DecodeExact(2, []byte{0x80, 0x00}, 1); err != io.ErrUnexpectedEOF {
+		t.Fatal("Partial codeword: expected io.ErrUnexpectedEOF, got ", err)
+	}
+	if res, err := DecodeExact(2, nil, 0); err != nil || res == nil || len(res) != 0 {
+		t.Fatal("Empty stream gave ", res, err)
+	}
+	if _, err := DecodeExact(2, []byte{0xff}, 1<<40); err != ErrCountMismatch {
+		t.Fatal("Expected ErrCountMismatch, got ", err)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	vals := make([]int, 300)
+	for i := range vals {
+		vals[i] = rand.Intn(2001) - 1000
+	}
+	// A pipe-like consumer:  bytes.Buffer wrapped so that the encoder
+	// has to go through bufio.
+	consumer := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(struct{ io.Writer }{consumer})
+	seen := 0
+	for i, v := range vals {
+		encoder.WriteInt(v)
+		if i%7 == 0 {
+			n, err := encoder.Flush()
+			if err != nil {
+				t.Fatal("Flush failed: ", err)
+			}
+			if n != consumer.Len()-seen {
+				t.Fatalf("Flush reported %d bytes, consumer received %d", n, consumer.Len()-seen)
+			}
+			seen = consumer.Len()
+		}
+	}
+	encoder.Close()
+
+	res := make([]int, len(vals))
+	n, _ := NewExpGolombDecoder(consumer).Read(res)
+	if n != len(vals) {
+		t.Fatalf("Got %d values, want %d", n, len(vals))
+	}
+	for i := range vals {
+		if res[i] != vals[i] {
+			t.Fatalf("item %d was %d, expected %d", i, res[i], vals[i])
+		}
+	}
+}
+
+// referenceDecode is a deliberately naive bit-by-bit decoder used to
+// check the optimized paths in Read.
+func referenceDecode(data []byte) []int {
+	res := make([]int, 0)
+	pos, end := 0, 8*len(data)
+	bit := func() uint {
+		b := uint(data[pos/8]>>(7-uint(pos%8))) & 1
+		pos++
+		return b
+	}
+	for {
+		zeros := 0
+		for pos < end && data[pos/8]>>(7-uint(pos%8))&1 == 0 {
+			zeros++
+			pos++
+		}
+		if pos+1 > end || (zeros > 0 && pos+zeros+2 > end) {
+			return res
+		}
+		bit() // the leading one
+		if zeros == 0 {
+			res = append(res, 0)
+			continue
+		}
+		val := uint(1)
+		for i := 0; i < zeros; i++ {
+			val = val<<1 | bit()
+		}
+		v := int(val - 1)
+		if bit() == 1 {
+			v = -v
+		}
+		res = append(res, v)
+	}
+}
+
+func TestSingleValueBytes(t *testing.T) {
+	cases := []struct {
+		v    int
+		want []byte
+	}{
+		{0, []byte{0x80}},
+		{-1, []byte{0x50}},
+		{3, []byte{0x20}},
+		{-8, []byte{0x13}},
+		{100, []byte{0x03, 0x28}},
+		{1 << 40, []byte{0, 0, 0, 0, 0, 0x80, 0, 0, 0, 0, 0x80}},
+	}
+	for _, c := range cases {
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoder(buf)
+		egs.WriteInt(c.v)
+		egs.Close()
+		if !bytes.Equal(buf.Bytes(), c.want) {
+			t.Errorf("Value %d encoded as %x, want %x", c.v, buf.Bytes(), c.want)
+		}
+	}
+}
+
+func TestEmitMatchesReference(t *testing.T) {
+	data := make([]int, 20000)
+	v := 0
+	for i := range data {
+		switch i % 3 {
+		case 0:
+			v += rand.Intn(17) - 8
+		case 1:
+			v += rand.Intn(1<<20) - 1<<19
+		default:
+			v = rand.Int() - rand.Int()
+		}
+		data[i] = v
+	}
+	// Each codeword written one bit at a time, as the stream
+	// should come out.
+	bits := make([]byte, 0)
+	appendBits := func(v uint, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, byte(v>>uint(i)&1))
+		}
+	}
+	for _, d := range Deltas(3, data) {
+		if d == 0 {
+			appendBits(1, 1)
+			continue
+		}
+		mag := uint(d)
+		if d < 0 {
+			mag = uint(-d)
+		}
+		zeros := codeLen(d)/2 - 1
+		appendBits(0, zeros)
+		appendBits(mag+1, zeros+1)
+		if d < 0 {
+			appendBits(1, 1)
+		} else {
+			appendBits(0, 1)
+		}
+	}
+	want := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		want[i/8] |= b << uint(7-i%8)
+	}
+	if !bytes.Equal(DeltaEncode(3, data), want) {
+		t.Fatal("Encoded bytes differ from the bit-by-bit reference")
+	}
+}
+
+func TestEncoderCounters(t *testing.T) {
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	if egs.ValuesWritten() != 0 || egs.BytesFlushed() != 0 {
+		t.Fatal("New encoder has nonzero counters")
+	}
+	// 100 zeros are 100 bits:  one full word emitted, 36 bits held.
+	egs.Write(make([]int, 100))
+	if egs.ValuesWritten() != 100 || egs.BytesFlushed() != 8 {
+		t.Fatalf("Got %d values, %d bytes", egs.ValuesWritten(), egs.BytesFlushed())
+	}
+	egs.WriteInt(-5)
+	egs.WriteUnsigned(9)
+	egs.WriteAll([]int{1, 2})
+	if egs.ValuesWritten() != 104 {
+		t.Fatalf("Got %d values, want 104", egs.ValuesWritten())
+	}
+	// 36 + 6 + 7 + 4 + 4 bits leave 57, padded to 8 bytes by Close.
+	egs.Close()
+	if egs.BytesFlushed() != 16 || buf.Len() != 16 {
+		t.Fatalf("Flushed %d bytes, buffer holds %d, want 16", egs.BytesFlushed(), buf.Len())
+	}
+}
+
+func TestZeroRunSkipAlignments(t *testing.T) {
+	vals := make([]int, 0)
+	for i := 0; i < 3000; i++ {
+		vals = append(vals, rand.Int()>>uint(rand.Intn(64)), -(rand.Int() >> uint(rand.Intn(64))))
+	}
+	for align := uint(0); align < 8; align++ {
+		buf := &bytes.Buffer{}
+		egs := NewExpGolombEncoder(buf)
+		egs.WriteRawBits(0, align)
+		egs.Write(vals)
+		egs.Close()
+		stream := buf.Bytes()
+
+		// Both the table-driven Read and the plain bit-at-a-time
+		// state machine must agree with the input.
+		d := NewExpGolombDecoder(bytes.NewReader(stream))
+		d.ReadRawBits(align)
+		res := make([]int, len(vals)+1)
+		if n, _ := d.Read(res); n != len(vals) {
+			t.Fatalf("Align %d: got %d values, want %d", align, n, len(vals))
+		}
+		ref := make([]int, 0)
+		var state DecodeState
+		for pos := int(align); pos < 8*len(stream); pos++ {
+			v, emitted := 0, false
+			state, emitted, v, _ = DecodeStep(state, uint(stream[pos/8]>>uint(7-pos%8))&1)
+			if emitted {
+				ref = append(ref, v)
+			}
+		}
+		for i := range vals {
+			if res[i] != vals[i] || ref[i] != vals[i] {
+				t.Fatalf("Align %d: item %d was %d, reference %d, expected %d", align, i, res[i], ref[i], vals[i])
+			}
+		}
+	}
+}
+
+// Streams of large values are mostly whole zero bytes in the prefix,
+// which Read skips a byte at a time.
+func TestZeroByteSkip(t *testing.T) {
+	vals := make([]int, 0)
+	for shift := uint(0); shift < 63; shift++ {
+		vals = append(vals, 1<<shift, -(1 << shift), 1<<shift+int(shift), 0)
+	}
+	// Lead with 0..7 single-bit zeros to shift through every alignment.
+	for lead := 0; lead < 8; lead++ {
+		buf := &bytes.Buffer{}
+		encoder := NewExpGolombEncoder(buf)
+		for i := 0; i < lead; i++ {
+			encoder.WriteInt(0)
+		}
+		encoder.Write(vals)
+		encoder.Close()
+		stream := buf.Bytes()
+
+		want := referenceDecode(stream)
+		res := make([]int, len(want)+1)
+		n, _ := NewExpGolombDecoder(bytes.NewReader(stream)).Read(res)
+		if n != len(want) || n != lead+len(vals) {
+			t.Fatalf("Lead %d: Read gave %d values, reference %d", lead, n, len(want))
+		}
+		for i := range want {
+			if res[i] != want[i] {
+				t.Fatalf("Lead %d: item %d was %d, reference %d", lead, i, res[i], want[i])
+			}
+		}
+	}
+
+	// The MaxBits cap still applies when zeros arrive a byte at a time.
+	decoder := NewExpGolombDecoder(bytes.NewReader([]byte{0, 0, 0, 0x80}))
+	decoder.SetMaxBits(20)
+	if _, err := decoder.Read(make([]int, 1)); err != ErrBitOrder {
+		t.Fatal("Expected ErrBitOrder, got ", err)
+	}
+}
+
+func TestDrainChecksum(t *testing.T) {
+	vals := []int{5, -3, 0, 1 << 40, 77, -77, 12}
+	want := 0
+	for _, v := range vals {
+		want ^= v
+	}
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoder(buf)
+	encoder.Write(vals)
+	encoder.Close()
+	stream := buf.Bytes()
+
+	count, xor, err := NewExpGolombDecoder(bytes.NewReader(stream)).DrainChecksum()
+	if count != len(vals) || xor != want || err != nil {
+		t.Fatalf("DrainChecksum = (%d, %d, %v), want (%d, %d, nil)", count, xor, err, len(vals), want)
+	}
+
+	// Cut inside the 1<<40 codeword.
+	count, _, err = NewExpGolombDecoder(bytes.NewReader(stream[:4])).DrainChecksum()
+	if count != 3 || err != io.ErrUnexpectedEOF {
+		t.Fatalf("Truncated DrainChecksum = (%d, %v), want (3, io.ErrUnexpectedEOF)", count, err)
+	}
+}
+
+// countingWriter counts the Write calls it receives.
+type countingWriter struct {
+	bytes.Buffer
+	calls int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.calls++
+	return c.Buffer.Write(p)
+}
+
+func TestAutoFlush(t *testing.T) {
+	vals := make([]int, 2000)
+	for i := range vals {
+		vals[i] = rand.Intn(4001) - 2000
+	}
+	const threshold = 16
+	sink := &countingWriter{}
+	// Hide WriteByte so that the encoder buffers with bufio.
+	egs := NewExpGolombEncoderAutoFlush(struct{ io.Writer }{sink}, threshold)
+	nbits := 0
+	for _, v := range vals {
+		egs.WriteInt(v)
+		nbits += codeLen(v)
+		if held := nbits/8 - sink.Len(); held >= threshold {
+			t.Fatalf("After %d bits, %d bytes still held", nbits, held)
+		}
+	}
+	egs.Close()
+	if sink.calls < nbits/8/(threshold+8) {
+		t.Fatalf("Only %d writes for %d bytes", sink.calls, sink.Len())
+	}
+
+	res := make([]int, len(vals)+1)
+	n, _ := NewExpGolombDecoder(sink).Read(res)
+	if n != len(vals) {
+		t.Fatalf("Decoded %d values, want %d", n, len(vals))
+	}
+	for i := range vals {
+		if res[i] != vals[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], vals[i])
+		}
+	}
+}
+
+func TestEncoderSize(t *testing.T) {
+	vals := make([]int, 20000)
+	for i := range vals {
+		vals[i] = rand.Intn(20001) - 10000
+	}
+	want := make([]int, len(vals))
+	calls := make([]int, 0)
+	for _, size := range []int{0, 16, 4096, 65536} {
+		// Hide WriteByte so the encoder has to use bufio.
+		cw := &countingWriter{}
+		encoder := NewExpGolombEncoderSize(struct{ io.Writer }{cw}, size)
+		encoder.Write(vals)
+		encoder.Close()
+		calls = append(calls, cw.calls)
+
+		n, _ := NewExpGolombDecoder(&cw.Buffer).Read(want)
+		if n != len(vals) {
+			t.Fatalf("Size %d: got %d values, want %d", size, n, len(vals))
+		}
+		for i := range vals {
+			if want[i] != vals[i] {
+				t.Fatalf("Size %d: item %d was %d, expected %d", size, i, want[i], vals[i])
+			}
+		}
+	}
+	if calls[1] <= calls[2] || calls[2] <= calls[3] {
+		t.Errorf("Expected fewer writes with bigger buffers, got %v", calls)
+	}
+}
+
+var benchvals = []int{0, 1, -1, 2, -5}
+
+// Reports allocations for DeltaEncode, which writes straight into a
+// bytes.Buffer.
+func BenchmarkDeltaEncode(b *testing.B) {
+	b.ReportAllocs()
+	data := make([]int, 1000)
+	for i := range data {
+		data[i] = i * 3
+	}
+	for i := 0; i < b.N; i++ {
+		DeltaEncode(0, data)
+	}
+}
+
+var smallResiduals = func() []int {
+	vals := make([]int, 1000)
+	r := rand.New(rand.NewSource(1))
+	for i := range vals {
+		vals[i] = r.Intn(2*smallCodeMax+1) - smallCodeMax
+	}
+	return vals
+}()
+
+// Compare with BenchmarkEncodeSmallGeneral for the gain from the
+// smallCodes table.
+func BenchmarkEncodeSmall(b *testing.B) {
+	egs := NewExpGolombEncoder(ioutil.Discard)
+	for i := 0; i < b.N; i++ {
+		for _, v := range smallResiduals {
+			egs.add(v)
+		}
+	}
+	egs.Close()
+}
+
+func BenchmarkEncodeSmallGeneral(b *testing.B) {
+	egs := NewExpGolombEncoder(ioutil.Discard)
+	for i := 0; i < b.N; i++ {
+		for _, v := range smallResiduals {
+			egs.addGeneral(v)
+		}
+	}
+	egs.Close()
+}
+
+func BenchmarkSingleValueEncode(b *testing.B) {
+	b.ReportAllocs()
+	buf := &bytes.Buffer{}
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		egs := NewExpGolombEncoder(buf)
+		egs.WriteInt(i & 0xff)
+		egs.Close()
+	}
+}
+
+var largeBenchData = func() []int {
+	data := make([]int, 100000)
+	r := rand.New(rand.NewSource(1))
+	v := 0
+	for i := range data {
+		v += r.Intn(2001) - 1000
+		data[i] = v
+	}
+	return data
+}()
+
+func BenchmarkLargeEncode(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		DeltaEncode(0, largeBenchData)
+	}
+}
+
+func BenchmarkExpGEncode(b *testing.B) {
+	b.StopTimer()
+
+	egs := NewExpGolombEncoder(ioutil.Discard)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		egs.Write(benchvals)
+	}
+	egs.Close()
+}
+
+// Benchmarks decode speed.  Because it resets the buffer
+// and does some other work, this test decodes 200 symbols
+// per iteration, so divde the ns/op by 200 to find
+// the per-symbol cost.
+func BenchmarkExpGDecode(b *testing.B) {
+	b.StopTimer()
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	for i := 0; i < 40; i++ {
+		egs.Write(benchvals)
+	}
+	egs.Close()
+
+	bbytes := buf.Bytes()
+	saved_b := make([]byte, len(bbytes))
+	copy(saved_b, bbytes)
+
+	b.StartTimer()
+	res := make([]int, 200)
+	for i := 0; i < b.N; i++ {
+		decoder := NewExpGolombDecoder(buf)
+		n, _ := decoder.Read(res)
+		if n != 200 {
+			b.Fatalf("Expected 200 ints, got %d", n)
+		}
+		buf.Reset()
+		buf.Write(saved_b)
+	}
+}
+
+// Values of a few hundred have zero runs of seven or eight bits,
+// which rarely fill an aligned byte.
+func BenchmarkExpGDecodeMedium(b *testing.B) {
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		egs.WriteInt(r.Intn(800) - 400)
+	}
+	egs.Close()
+	stream := buf.Bytes()
+
+	res := make([]int, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewExpGolombDecoder(bytes.NewReader(stream)).Read(res)
+	}
+}
+
+// Decode speed on large values, whose codewords are dominated by
+// long zero prefixes.
+func BenchmarkExpGDecodeLarge(b *testing.B) {
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	for i := 0; i < 200; i++ {
+		egs.WriteInt(1<<40 + i)
+	}
+	egs.Close()
+	stream := buf.Bytes()
+
+	res := make([]int, 200)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoder := NewExpGolombDecoder(bytes.NewReader(stream))
+		if n, _ := decoder.Read(res); n != 200 {
+			b.Fatalf("Expected 200 ints, got %d", n)
+		}
+	}
+}
+
+// Reports underlying Write calls per encode for a small and a large
+// bufio buffer.
+func BenchmarkExpGEncodeBufSize(b *testing.B) {
+	vals := make([]int, 100000)
+	for i := range vals {
+		vals[i] = i % 1000
+	}
+	for _, size := range []int{512, 65536} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			calls := 0
+			for i := 0; i < b.N; i++ {
+				cw := &countingWriter{}
+				encoder := NewExpGolombEncoderSize(struct{ io.Writer }{cw}, size)
+				encoder.Write(vals)
+				encoder.Close()
+				calls += cw.calls
+			}
+			b.ReportMetric(float64(calls)/float64(b.N), "writes/op")
+		})
+	}
+}
+
+// deltaDecodeOneAtATime is DeltaDecode as it was before it read in
+// chunks, kept for comparison.
+func deltaDecodeOneAtATime(base int, compressed []byte) []int {
+	res := make([]int, 0)
+	val := base
+	decoder := NewExpGolombDecoder(bytes.NewBuffer(compressed))
+
+	tmp := make([]int, 1)
+	for {
+		n, err := decoder.Read(tmp)
+		if n > 0 {
+			val = val + tmp[0]
+			res = append(res, val)
+		}
+		if err != nil {
+			return res
+		}
+	}
+}
+
+func BenchmarkDeltaDecode(b *testing.B) {
+	data := make([]int, 100000)
+	for i := range data {
+		data[i] = i*3 + rand.Intn(5)
+	}
+	compressed := DeltaEncode(0, data)
+	b.Run("chunked", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			DeltaDecode(0, compressed)
+		}
+	})
+	b.Run("one-at-a-time", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			deltaDecodeOneAtATime(0, compressed)
+		}
+	})
+}
+
+// Residual distributions for the encode and decode suites, 10000
+// values each from a fixed seed.
+var benchDists = func() []struct {
+	name string
+	vals []int
+} {
+	r := rand.New(rand.NewSource(1))
+	gen := func(f func() int) []int {
+		vals := make([]int, 10000)
+		for i := range vals {
+			vals[i] = f()
+		}
+		return vals
+	}
+	return []struct {
+		name string
+		vals []int
+	}{
+		{"zeros", make([]int, 10000)},
+		{"small", gen(func() int { return r.Intn(7) - 3 })},
+		{"large", gen(func() int { return r.Intn(1<<30) + 1<<30 })},
+		{"mixed", gen(func() int {
+			// Mostly small, either sign, with the odd large outlier.
+			if r.Intn(20) == 0 {
+				return r.Intn(1<<24) - 1<<23
+			}
+			return r.Intn(101) - 50
+		})},
+	}
+}()
+
+func BenchmarkEncodeDist(b *testing.B) {
+	for _, d := range benchDists {
+		b.Run(d.name, func(b *testing.B) {
+			b.ReportAllocs()
+			buf := &bytes.Buffer{}
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				egs := NewExpGolombEncoder(buf)
+				egs.Write(d.vals)
+				egs.Close()
+			}
+			b.SetBytes(int64(buf.Len()))
+		})
+	}
+}
+
+func BenchmarkDecodeDist(b *testing.B) {
+	for _, d := range benchDists {
+		b.Run(d.name, func(b *testing.B) {
+			buf := &bytes.Buffer{}
+			egs := NewExpGolombEncoder(buf)
+			egs.Write(d.vals)
+			egs.Close()
+			stream := buf.Bytes()
+			res := make([]int, len(d.vals))
+			b.SetBytes(int64(len(stream)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if n, _ := NewExpGolombDecoder(bytes.NewReader(stream)).Read(res); n != len(res) {
+					b.Fatalf("Expected %d ints, got %d", len(res), n)
+				}
+			}
+		})
+	}
+}
+
+// add alone, one op per value, without the bookkeeping of the public
+// write methods.
+func BenchmarkAdd(b *testing.B) {
+	vals := benchDists[3].vals
+	egs := NewExpGolombEncoder(ioutil.Discard)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		egs.add(vals[i%len(vals)])
+	}
+	egs.Close()
+}
+
+// Read's inner loop, one op per value:  the decoder and reader are
+// set up outside the timer and reused until the stream runs out.
+func BenchmarkReadLoop(b *testing.B) {
+	vals := benchDists[3].vals
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	egs.Write(vals)
+	egs.Close()
+	stream := buf.Bytes()
+	res := make([]int, len(vals))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for done := 0; done < b.N; done += len(vals) {
+		b.StopTimer()
+		decoder := NewExpGolombDecoder(bytes.NewReader(stream))
+		out := res
+		if b.N-done < len(out) {
+			out = out[:b.N-done]
+		}
+		b.StartTimer()
+		decoder.Read(out)
 	}
 }
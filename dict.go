@@ -0,0 +1,119 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+var ErrDictIndex = errors.New("deltagolomb: dictionary index out of range")
+
+// EncodeWithDict codes values against a small dictionary of common
+// values.  A value found in dict is written as a 1 flag bit and its
+// index in fixed width (just wide enough for len(dict)-1); any other
+// value is written as a 0 flag bit and its ordinary Exp-Golomb
+// codeword.  The decoder must be given an identical dict.
+func EncodeWithDict(w io.Writer, dict []int, values []int) error {
+	index := make(map[int]uint, len(dict))
+	for i := len(dict) - 1; i >= 0; i-- {
+		index[dict[i]] = uint(i)
+	}
+	width := dictIndexBits(dict)
+
+	egs := NewExpGolombEncoder(w)
+	for _, v := range values {
+		if i, ok := index[v]; ok {
+			egs.addBits(1, 1)
+			egs.addBits(i, width)
+		} else {
+			egs.addBits(0, 1)
+			egs.add(v)
+		}
+	}
+	return egs.Close()
+}
+
+// DecodeWithDict reverses EncodeWithDict.
+func DecodeWithDict(r io.Reader, dict []int) ([]int, error) {
+	res := make([]int, 0)
+	width := dictIndexBits(dict)
+	decoder := NewExpGolombDecoder(r)
+	tmp := make([]int, 1)
+	for {
+		flag, err := decoder.readBit()
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+
+		if flag == 1 {
+			i := uint(0)
+			for b := uint(0); b < width; b++ {
+				bit, err := decoder.readBit()
+				if err != nil {
+					if err == io.EOF {
+						err = io.ErrUnexpectedEOF
+					}
+					return res, err
+				}
+				i = i<<1 | bit
+			}
+			if i >= uint(len(dict)) {
+				return res, ErrDictIndex
+			}
+			res = append(res, dict[i])
+			continue
+		}
+
+		if n, err := decoder.Read(tmp); n == 0 {
+			// A miss flag followed only by a few zeros is the padding
+			// at the end of the stream.
+			if err == io.EOF && decoder.truncated() {
+				err = io.ErrUnexpectedEOF
+			}
+			if err == io.EOF {
+				return res, nil
+			}
+			return res, err
+		}
+		res = append(res, tmp[0])
+	}
+}
+
+// DecodeMapped decodes a delta-coded stream of indices, as written by
+// DeltaEncode(base, indices), and returns table[index] for each one
+// in a single pass.  An index outside table gives ErrDictIndex along
+// with the values mapped before it, and data that ends partway
+// through a codeword gives io.ErrUnexpectedEOF.
+func DecodeMapped(base int, compressed []byte, table []int) ([]int, error) {
+	res := make([]int, 0)
+	index := base
+	decoder := NewExpGolombDecoder(bytes.NewReader(compressed))
+	tmp := make([]int, 256)
+	for {
+		n, err := decoder.Read(tmp)
+		for _, d := range tmp[:n] {
+			index += d
+			if index < 0 || index >= len(table) {
+				return res, ErrDictIndex
+			}
+			res = append(res, table[index])
+		}
+		if err == io.EOF && decoder.truncated() {
+			return res, io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+	}
+}
+
+func dictIndexBits(dict []int) uint {
+	if len(dict) < 2 {
+		return 0
+	}
+	return uint(bitLen(uint(len(dict) - 1)))
+}
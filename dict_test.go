@@ -0,0 +1,108 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeWithDict(t *testing.T) {
+	dict := []int{37, -112, 500, 9}
+	values := make([]int, 5000)
+	for i := range values {
+		if rand.Intn(10) < 9 {
+			values[i] = dict[rand.Intn(len(dict))]
+		} else {
+			values[i] = rand.Intn(2001) - 1000
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := EncodeWithDict(buf, dict, values); err != nil {
+		t.Fatal(err)
+	}
+	dictSize := buf.Len()
+	res, err := DecodeWithDict(buf, dict)
+	if err != nil {
+		t.Fatal("DecodeWithDict failed: ", err)
+	}
+	if len(res) != len(values) {
+		t.Fatalf("Got %d values, want %d", len(res), len(values))
+	}
+	for i := range values {
+		if res[i] != values[i] {
+			t.Fatalf("item %d was %d, expected %d", i, res[i], values[i])
+		}
+	}
+
+	plain := &bytes.Buffer{}
+	e := NewExpGolombEncoder(plain)
+	e.Write(values)
+	e.Close()
+	if dictSize >= plain.Len() {
+		t.Errorf("Dictionary coding took %d bytes, plain Exp-Golomb %d", dictSize, plain.Len())
+	}
+}
+
+func TestDictEdgeCases(t *testing.T) {
+	for _, dict := range [][]int{nil, {4}, {1, 2, 3}} {
+		values := []int{4, 0, 0, 1, -7, 3, 0}
+		buf := &bytes.Buffer{}
+		EncodeWithDict(buf, dict, values)
+		res, err := DecodeWithDict(buf, dict)
+		if err != nil || len(res) != len(values) {
+			t.Fatalf("Dict %v: got %v, %v", dict, res, err)
+		}
+		for i := range values {
+			if res[i] != values[i] {
+				t.Fatalf("Dict %v: item %d was %d, expected %d", dict, i, res[i], values[i])
+			}
+		}
+	}
+
+	// Index 3 with a three-entry dictionary: flag 1, index 11.
+	if _, err := DecodeWithDict(bytes.NewBuffer([]byte{0xe0}), []int{1, 2, 3}); err != ErrDictIndex {
+		t.Fatal("Expected ErrDictIndex, got ", err)
+	}
+}
+
+func TestDecodeMapped(t *testing.T) {
+	table := []int{-40, 7, 1000, 3}
+	indices := []int{2, 2, 0, 3, 1, 1, 0}
+	res, err := DecodeMapped(1, DeltaEncode(1, indices), table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(indices) {
+		t.Fatalf("Got %d values, want %d", len(res), len(indices))
+	}
+	for i, idx := range indices {
+		if res[i] != table[idx] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], table[idx])
+		}
+	}
+
+	for _, bad := range []int{4, -1} {
+		res, err = DecodeMapped(0, DeltaEncode(0, []int{1, 3, bad, 0}), table)
+		if err != ErrDictIndex {
+			t.Fatalf("Index %d: expected ErrDictIndex, got %v", bad, err)
+		}
+		if len(res) != 2 {
+			t.Fatalf("Index %d: expected 2 values before the error, got %v", bad, res)
+		}
+	}
+	// The second codeword, index 1500, is 22 bits long; cut it short.
+	table = make([]int, 2000)
+	for i := range table {
+		table[i] = i * i
+	}
+	blob := DeltaEncode(0, []int{5, 1500})
+	res, err = DecodeMapped(0, blob[:len(blob)-1], table)
+	if err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF for truncated data, got ", err)
+	}
+	if len(res) != 1 || res[0] != 25 {
+		t.Fatal("Expected [25] before the truncation, got ", res)
+	}
+}
@@ -0,0 +1,37 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrDiffLength = errors.New("deltagolomb: old and new slices differ in length")
+
+// EncodeDiff Exp-Golomb codes the element-wise differences new[i] -
+// old[i] of two slices of equal length.  Unlike delta coding the
+// residuals are taken across the two slices, not along either, so a
+// mostly unchanged slice codes to mostly one-bit zeros.
+func EncodeDiff(w io.Writer, old, new []int) error {
+	if len(old) != len(new) {
+		return ErrDiffLength
+	}
+	egs := NewExpGolombEncoder(w)
+	for i := range new {
+		egs.WriteInt(new[i] - old[i])
+	}
+	return egs.Close()
+}
+
+// DecodeDiff reverses EncodeDiff, rebuilding new from old.  A stream
+// holding a different number of residuals than len(old) gives
+// ErrDiffLength.
+func DecodeDiff(old []int, compressed []byte) ([]int, error) {
+	res := DecodeResiduals(compressed)
+	if len(res) != len(old) {
+		return nil, ErrDiffLength
+	}
+	for i := range res {
+		res[i] += old[i]
+	}
+	return res, nil
+}
@@ -0,0 +1,50 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeDiff(t *testing.T) {
+	old := make([]int, 4000)
+	for i := range old {
+		old[i] = rand.Int() >> 20
+	}
+	mostly := make([]int, len(old))
+	copy(mostly, old)
+	for i := 0; i < 40; i++ {
+		mostly[rand.Intn(len(mostly))] += rand.Intn(201) - 100
+	}
+	changed := make([]int, len(old))
+	for i := range changed {
+		changed[i] = rand.Int() >> 20
+	}
+
+	for _, new := range [][]int{mostly, changed, old} {
+		buf := &bytes.Buffer{}
+		if err := EncodeDiff(buf, old, new); err != nil {
+			t.Fatal(err)
+		}
+		size := buf.Len()
+		res, err := DecodeDiff(old, buf.Bytes())
+		if err != nil {
+			t.Fatal("DecodeDiff failed: ", err)
+		}
+		for i := range new {
+			if res[i] != new[i] {
+				t.Fatalf("Item %d was %d, expected %d", i, res[i], new[i])
+			}
+		}
+		if &new[0] == &old[0] && size != len(old)/8 {
+			t.Errorf("Unchanged slice took %d bytes, want %d", size, len(old)/8)
+		}
+	}
+
+	if err := EncodeDiff(&bytes.Buffer{}, old, old[1:]); err != ErrDiffLength {
+		t.Fatal("Expected ErrDiffLength, got ", err)
+	}
+	if _, err := DecodeDiff(old[1:], DeltaEncode(0, old)); err != ErrDiffLength {
+		t.Fatal("Expected ErrDiffLength, got ", err)
+	}
+}
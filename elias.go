@@ -0,0 +1,127 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrNotPositive = errors.New("deltagolomb: Elias codes cannot represent zero")
+
+// Elias gamma coding of n >= 1 is floor(log2 n) zeros followed by n
+// in binary, which is exactly the unsigned Exp-Golomb codeword for
+// n-1.  The gamma codec is therefore a thin shim over the unsigned
+// Exp-Golomb code.
+type EliasGammaEncoder struct {
+	e *ExpGolombEncoder
+}
+
+type EliasGammaDecoder struct {
+	d *ExpGolombDecoder
+}
+
+// Elias delta coding of n >= 1 gamma-codes the bit length of n and
+// then writes n's bits below the leading one.  It is longer than
+// gamma for small values but grows only logarithmically in the
+// length of n.
+type EliasDeltaEncoder struct {
+	e *ExpGolombEncoder
+}
+
+type EliasDeltaDecoder struct {
+	d *ExpGolombDecoder
+}
+
+// Create a new Elias gamma encoder writing to w.  Users must call
+// Close() when finished.
+func NewEliasGammaEncoder(w io.Writer) *EliasGammaEncoder {
+	return &EliasGammaEncoder{NewExpGolombEncoder(w)}
+}
+
+// Create a new Elias gamma decoder reading from r.
+func NewEliasGammaDecoder(r io.Reader) *EliasGammaDecoder {
+	return &EliasGammaDecoder{NewExpGolombDecoder(r)}
+}
+
+// Create a new Elias delta encoder writing to w.  Users must call
+// Close() when finished.
+func NewEliasDeltaEncoder(w io.Writer) *EliasDeltaEncoder {
+	return &EliasDeltaEncoder{NewExpGolombEncoder(w)}
+}
+
+// Create a new Elias delta decoder reading from r.
+func NewEliasDeltaDecoder(r io.Reader) *EliasDeltaDecoder {
+	return &EliasDeltaDecoder{NewExpGolombDecoder(r)}
+}
+
+// Encode a slice of positive integers.  Stops with ErrNotPositive at
+// the first zero; the values before it are still encoded.
+func (s *EliasGammaEncoder) Write(vals []uint) error {
+	for _, v := range vals {
+		if v == 0 {
+			return ErrNotPositive
+		}
+		s.e.addUnsigned(v - 1)
+	}
+	return nil
+}
+
+func (s *EliasGammaEncoder) Close() error {
+	return s.e.Close()
+}
+
+// Decode gamma-coded integers into out.  Follows the same contract
+// as ExpGolombDecoder.Read.
+func (s *EliasGammaDecoder) Read(out []uint) (int, error) {
+	n, err := s.d.ReadUnsigned(out)
+	for i := range out[:n] {
+		out[i]++
+	}
+	return n, err
+}
+
+// Encode a slice of positive integers.  Stops with ErrNotPositive at
+// the first zero; the values before it are still encoded.
+func (s *EliasDeltaEncoder) Write(vals []uint) error {
+	for _, v := range vals {
+		if v == 0 {
+			return ErrNotPositive
+		}
+		nbits := uint(bitLen(v))
+		s.e.addUnsigned(nbits - 1) // gamma code of nbits
+		s.e.addBits(v&(1<<(nbits-1)-1), nbits-1)
+	}
+	return nil
+}
+
+func (s *EliasDeltaEncoder) Close() error {
+	return s.e.Close()
+}
+
+// Decode delta-coded integers into out.  Follows the same contract
+// as ExpGolombDecoder.Read, except that a stream ending partway
+// through a value's low bits returns io.ErrUnexpectedEOF.  A length
+// prefix of more than 64 bits gives ErrOverflow.
+func (s *EliasDeltaDecoder) Read(out []uint) (int, error) {
+	tmp := make([]uint, 1)
+	for i := range out {
+		if n, err := s.d.ReadUnsigned(tmp); n == 0 {
+			return i, err
+		}
+		if tmp[0] >= 64 {
+			return i, ErrOverflow
+		}
+		v := uint(1)
+		for b := uint(0); b < tmp[0]; b++ {
+			bit, err := s.d.readBit()
+			if err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return i, err
+			}
+			v = v<<1 | bit
+		}
+		out[i] = v
+	}
+	return len(out), nil
+}
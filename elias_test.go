@@ -0,0 +1,141 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+var eliasGammaTests = []etest{
+	{[]int{1}, []byte{0x80}}, // 0b1
+	{[]int{2}, []byte{0x40}}, // 0b010
+	{[]int{3}, []byte{0x60}}, // 0b011
+	{[]int{4}, []byte{0x20}}, // 0b00100
+}
+
+var eliasDeltaTests = []etest{
+	{[]int{1}, []byte{0x80}},        // 0b1
+	{[]int{2}, []byte{0x40}},        // 0b010 0
+	{[]int{3}, []byte{0x50}},        // 0b010 1
+	{[]int{4}, []byte{0x60}},        // 0b011 00
+	{[]int{17}, []byte{0x28, 0x80}}, // 0b00101 0001
+}
+
+func TestEliasBytes(t *testing.T) {
+	for _, bt := range eliasGammaTests {
+		buf := &bytes.Buffer{}
+		e := NewEliasGammaEncoder(buf)
+		e.Write([]uint{uint(bt.ints[0])})
+		e.Close()
+		if bytes.Compare(buf.Bytes(), bt.bytes) != 0 {
+			t.Fatal("Gamma encode of ", bt.ints, " gave ", buf.Bytes(), " expected ", bt.bytes)
+		}
+	}
+	for _, bt := range eliasDeltaTests {
+		buf := &bytes.Buffer{}
+		e := NewEliasDeltaEncoder(buf)
+		e.Write([]uint{uint(bt.ints[0])})
+		e.Close()
+		if bytes.Compare(buf.Bytes(), bt.bytes) != 0 {
+			t.Fatal("Delta encode of ", bt.ints, " gave ", buf.Bytes(), " expected ", bt.bytes)
+		}
+	}
+}
+
+type uintReader interface {
+	Read(out []uint) (int, error)
+}
+
+func checkElias(t *testing.T, name string, vals []uint, d uintReader) {
+	res := make([]uint, len(vals)+1)
+	n, err := d.Read(res)
+	if n != len(vals) || err != io.EOF {
+		t.Fatalf("%s: got (%d, %v), want (%d, io.EOF)", name, n, err, len(vals))
+	}
+	for i := range vals {
+		if res[i] != vals[i] {
+			t.Fatalf("%s: item %d was %d, expected %d", name, i, res[i], vals[i])
+		}
+	}
+}
+
+func TestEliasRoundTrip(t *testing.T) {
+	vals := make([]uint, 0)
+	for i := uint(1); i < 5000; i++ {
+		vals = append(vals, i)
+	}
+	for i := 0; i < 1000; i++ {
+		vals = append(vals, uint(rand.Int63())+1)
+	}
+	vals = append(vals, ^uint(0), 1<<63)
+
+	gbuf := &bytes.Buffer{}
+	g := NewEliasGammaEncoder(gbuf)
+	if err := g.Write(vals); err != nil {
+		t.Fatal(err)
+	}
+	g.Close()
+	checkElias(t, "gamma", vals, NewEliasGammaDecoder(gbuf))
+
+	dbuf := &bytes.Buffer{}
+	d := NewEliasDeltaEncoder(dbuf)
+	if err := d.Write(vals); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	checkElias(t, "delta", vals, NewEliasDeltaDecoder(dbuf))
+
+	if err := NewEliasGammaEncoder(gbuf).Write([]uint{1, 0}); err != ErrNotPositive {
+		t.Fatal("Expected ErrNotPositive from gamma, got ", err)
+	}
+	if err := NewEliasDeltaEncoder(dbuf).Write([]uint{0}); err != ErrNotPositive {
+		t.Fatal("Expected ErrNotPositive from delta, got ", err)
+	}
+
+	// The largest uint, then a length prefix of 65 bits.
+	dbuf.Reset()
+	e := NewExpGolombEncoder(dbuf)
+	e.addUnsigned(63)
+	e.WriteRawBits(^uint64(0), 63)
+	e.addUnsigned(64)
+	e.WriteRawBits(0, 64)
+	e.Close()
+	out := make([]uint, 2)
+	if n, err := NewEliasDeltaDecoder(dbuf).Read(out); n != 1 || out[0] != ^uint(0) || err != ErrOverflow {
+		t.Fatalf("Read gave %d, %v, %x", n, err, out)
+	}
+}
+
+// Gamma costs exactly what unsigned Exp-Golomb does; delta loses on
+// small values and wins on large ones.
+func TestEliasSizes(t *testing.T) {
+	size := func(vals []uint) (eg, gamma, delta int) {
+		ebuf, gbuf, dbuf := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+		e := NewExpGolombEncoder(ebuf)
+		for _, v := range vals {
+			e.WriteUnsigned(v - 1)
+		}
+		e.Close()
+		g := NewEliasGammaEncoder(gbuf)
+		g.Write(vals)
+		g.Close()
+		d := NewEliasDeltaEncoder(dbuf)
+		d.Write(vals)
+		d.Close()
+		return ebuf.Len(), gbuf.Len(), dbuf.Len()
+	}
+
+	small := make([]uint, 1000)
+	large := make([]uint, 1000)
+	for i := range small {
+		small[i] = uint(rand.Intn(3)) + 1
+		large[i] = uint(rand.Int63n(1<<40)) + 1<<40
+	}
+	if eg, gamma, delta := size(small); eg != gamma || delta <= gamma {
+		t.Errorf("Small values: Exp-Golomb %d, gamma %d, delta %d bytes", eg, gamma, delta)
+	}
+	if eg, gamma, delta := size(large); eg != gamma || delta >= gamma {
+		t.Errorf("Large values: Exp-Golomb %d, gamma %d, delta %d bytes", eg, gamma, delta)
+	}
+}
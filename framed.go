@@ -0,0 +1,85 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	ErrSeriesCount = errors.New("deltagolomb: number of bases does not match number of series")
+	ErrFrameLength = errors.New("deltagolomb: implausible frame length")
+)
+
+// EncodeFramed writes each series as an independent delta-coded
+// sub-stream behind a small frame header:  its base as a signed
+// varint, then the length of the Exp-Golomb bytes as an unsigned
+// varint, then the bytes.  Series i is coded against bases[i].
+func EncodeFramed(w io.Writer, bases []int, series [][]int) error {
+	if len(bases) != len(series) {
+		return ErrSeriesCount
+	}
+	var hdr [2 * binary.MaxVarintLen64]byte
+	for i, data := range series {
+		frame := DeltaEncode(bases[i], data)
+		n := binary.PutVarint(hdr[:], int64(bases[i]))
+		n += binary.PutUvarint(hdr[n:], uint64(len(frame)))
+		if _, err := w.Write(hdr[:n]); err != nil {
+			return err
+		}
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeFramed reads frames written by EncodeFramed until r is
+// exhausted and decodes each one on its own.  A stream that ends
+// inside a frame gives io.ErrUnexpectedEOF along with the series
+// decoded before it.
+func DecodeFramed(r io.Reader) ([][]int, error) {
+	res := make([][]int, 0)
+	br := makeReader(r)
+	for {
+		base, err := binary.ReadVarint(br)
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+		frame, err := readFrame(br)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, DeltaDecode(int(base), frame))
+	}
+}
+
+// readFrame reads a length-prefixed frame:  the length as an unsigned
+// varint, then that many bytes.  The end of r anywhere in the frame
+// gives io.ErrUnexpectedEOF, and a length above 2^31 gives
+// ErrFrameLength.
+func readFrame(br byteReader) ([]byte, error) {
+	size, err := binary.ReadUvarint(br)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size > 1<<31 {
+		return nil, ErrFrameLength
+	}
+	// Copy rather than allocate size bytes up front:  the buffer only
+	// grows as far as the data actually goes.
+	frame := &bytes.Buffer{}
+	if n, err := io.CopyN(frame, br, int64(size)); n < int64(size) {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame.Bytes(), nil
+}
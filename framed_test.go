@@ -0,0 +1,69 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeFramed(t *testing.T) {
+	bases := []int{0, -1000, 1 << 40, 7}
+	series := make([][]int, len(bases))
+	for i, n := range []int{0, 1, 500, 3000} {
+		series[i] = make([]int, n)
+		v := bases[i]
+		for j := range series[i] {
+			v += rand.Intn(2001) - 1000
+			series[i][j] = v
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := EncodeFramed(buf, bases, series); err != nil {
+		t.Fatal(err)
+	}
+	stream := buf.Bytes()
+	// A plain io.Reader, so DecodeFramed has to buffer it itself.
+	res, err := DecodeFramed(io.LimitReader(bytes.NewReader(stream), int64(len(stream))))
+	if err != nil {
+		t.Fatal("DecodeFramed failed: ", err)
+	}
+	if len(res) != len(series) {
+		t.Fatalf("Got %d series, want %d", len(res), len(series))
+	}
+	for i := range series {
+		if len(res[i]) != len(series[i]) {
+			t.Fatalf("Series %d: got %d values, want %d", i, len(res[i]), len(series[i]))
+		}
+		for j := range series[i] {
+			if res[i][j] != series[i][j] {
+				t.Fatalf("Series %d item %d was %d, expected %d", i, j, res[i][j], series[i][j])
+			}
+		}
+	}
+
+	for _, cut := range []int{1, 5, len(stream) - 1} {
+		res, err := DecodeFramed(bytes.NewReader(stream[:cut]))
+		if err != io.ErrUnexpectedEOF {
+			t.Fatalf("Cut at %d: expected io.ErrUnexpectedEOF, got %v", cut, err)
+		}
+		if cut == len(stream)-1 && len(res) != 3 {
+			t.Fatalf("Cut at %d: got %d whole series, want 3", cut, len(res))
+		}
+	}
+	if err := EncodeFramed(buf, bases[1:], series); err != ErrSeriesCount {
+		t.Fatal("Expected ErrSeriesCount, got ", err)
+	}
+
+	// Base 0, then frame lengths of 2^31 and 2^31+1 with no data.
+	for _, hdr := range [][]byte{{0, 0x80, 0x80, 0x80, 0x80, 0x08}, {0, 0x81, 0x80, 0x80, 0x80, 0x08}} {
+		want := io.ErrUnexpectedEOF
+		if hdr[1] == 0x81 {
+			want = ErrFrameLength
+		}
+		if _, err := DecodeFramed(bytes.NewReader(hdr)); err != want {
+			t.Fatalf("Header %x: got %v, want %v", hdr, err, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package deltagolomb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	ErrRecordWidth   = errors.New("deltagolomb: record width must be 1, 2, 4 or 8 bytes")
+	ErrPartialRecord = errors.New("deltagolomb: stream ended partway through a record")
+)
+
+// intByteEncoder reads fixed-width little-endian signed integers out
+// of the bytes written to it and Exp-Golomb codes each one.
+type intByteEncoder struct {
+	e       *ExpGolombEncoder
+	width   int
+	partial []byte // start of a record split across Writes
+	err     error  // ErrRecordWidth, for every call
+}
+
+// NewIntByteEncoder returns a writer that takes a raw stream of
+// width-byte little-endian signed integers, such as a dump of an
+// []int32, and writes their Exp-Golomb codes to w.  The values are
+// coded as they are, with no delta stage.  Records may be split
+// across Write calls.  Close codes what remains and flushes; bytes
+// left over that do not make a whole record give ErrPartialRecord.
+// A width other than 1, 2, 4 or 8 makes every call fail with
+// ErrRecordWidth.
+func NewIntByteEncoder(w io.Writer, width int) io.WriteCloser {
+	s := &intByteEncoder{e: NewExpGolombEncoder(w), width: width}
+	if width != 1 && width != 2 && width != 4 && width != 8 {
+		s.err = ErrRecordWidth
+	}
+	return s
+}
+
+func (s *intByteEncoder) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	n := len(p)
+	if len(s.partial) > 0 {
+		fill := s.width - len(s.partial)
+		if fill > len(p) {
+			fill = len(p)
+		}
+		s.partial = append(s.partial, p[:fill]...)
+		p = p[fill:]
+		if len(s.partial) < s.width {
+			return n, nil
+		}
+		s.e.WriteInt(s.record(s.partial))
+		s.partial = s.partial[:0]
+	}
+	for ; len(p) >= s.width; p = p[s.width:] {
+		s.e.WriteInt(s.record(p))
+	}
+	s.partial = append(s.partial, p...)
+	if _, err := s.e.Flush(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// record decodes the width-byte integer at the start of b.
+func (s *intByteEncoder) record(b []byte) int {
+	switch s.width {
+	case 1:
+		return int(int8(b[0]))
+	case 2:
+		return int(int16(binary.LittleEndian.Uint16(b)))
+	case 4:
+		return int(int32(binary.LittleEndian.Uint32(b)))
+	}
+	return int(binary.LittleEndian.Uint64(b))
+}
+
+func (s *intByteEncoder) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.e.Close(); err != nil {
+		return err
+	}
+	if len(s.partial) > 0 {
+		return ErrPartialRecord
+	}
+	return nil
+}
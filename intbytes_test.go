@@ -0,0 +1,62 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+func TestIntByteEncoder(t *testing.T) {
+	vals := []int32{0, 1, -1, 1<<31 - 1, -1 << 31, 300}
+	for i := 0; i < 1000; i++ {
+		vals = append(vals, int32(rand.Intn(2001)-1000))
+	}
+	raw := &bytes.Buffer{}
+	binary.Write(raw, binary.LittleEndian, vals)
+	data := raw.Bytes()
+
+	for _, chunk := range []int{1, 3, 4, 7, 4096} {
+		buf := &bytes.Buffer{}
+		w := NewIntByteEncoder(buf, 4)
+		for p := data; len(p) > 0; {
+			n := chunk
+			if n > len(p) {
+				n = len(p)
+			}
+			if m, err := w.Write(p[:n]); m != n || err != nil {
+				t.Fatalf("Chunk %d: Write = (%d, %v)", chunk, m, err)
+			}
+			p = p[n:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Chunk %d: %v", chunk, err)
+		}
+
+		res := make([]int, len(vals)+1)
+		n, _ := NewExpGolombDecoder(buf).Read(res)
+		if n != len(vals) {
+			t.Fatalf("Chunk %d: got %d values, want %d", chunk, n, len(vals))
+		}
+		for i, v := range vals {
+			if res[i] != int(v) {
+				t.Fatalf("Chunk %d: item %d was %d, expected %d", chunk, i, res[i], v)
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	w := NewIntByteEncoder(buf, 2)
+	w.Write([]byte{0xff, 0xff, 0x02})
+	if err := w.Close(); err != ErrPartialRecord {
+		t.Fatal("Expected ErrPartialRecord, got ", err)
+	}
+	if res := DecodeAbsolute(buf.Bytes()); len(res) != 1 || res[0] != -1 {
+		t.Fatal("Expected the whole record, -1, got ", res)
+	}
+
+	w = NewIntByteEncoder(&bytes.Buffer{}, 3)
+	if _, err := w.Write([]byte{1, 2, 3}); err != ErrRecordWidth {
+		t.Fatal("Expected ErrRecordWidth, got ", err)
+	}
+}
@@ -0,0 +1,150 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+)
+
+var ErrNoStopBit = errors.New("deltagolomb: stream does not end with a stop bit")
+
+// InteropOptions describes the framing used by another Exp-Golomb
+// implementation.  The zero value is this package's own format.
+type InteropOptions struct {
+	// Unsigned values carry no sign information at all:  each
+	// codeword is a plain ue(v) and decodes to a nonnegative value.
+	Unsigned bool
+	// Sign selects how signed values are mapped when !Unsigned.
+	Sign SignMode
+	// StopBit streams end with a single 1 bit before the zero
+	// padding, as in H.264 rbsp_trailing_bits.
+	StopBit bool
+	// LSBFirst streams pack bits into each byte starting from the
+	// least significant bit.
+	LSBFirst bool
+}
+
+// InteropDecoder decodes Exp-Golomb streams written by other
+// implementations, as described by its InteropOptions.
+type InteropDecoder struct {
+	d    *ExpGolombDecoder
+	opts InteropOptions
+	tmp  []int
+	utmp []uint
+
+	// With StopBit, a one-bit codeword may be the stop bit, so one
+	// value is kept in hand until the value after it is seen.
+	ahead     int
+	aheadOne  bool // ahead came from a one-bit codeword
+	haveAhead bool
+	stopped   bool
+}
+
+// Create a decoder for a stream framed as opts describes.
+func NewInteropDecoder(r io.Reader, opts InteropOptions) *InteropDecoder {
+	if opts.LSBFirst {
+		r = &reverseBitsReader{makeReader(r)}
+	}
+	d := NewExpGolombDecoder(r)
+	return &InteropDecoder{d: d, opts: opts, tmp: make([]int, 1), utmp: make([]uint, 1)}
+}
+
+// Read decodes values into out, as ExpGolombDecoder.Read does.  With
+// StopBit set the final 1 bit is consumed rather than returned as a
+// value, whatever the sign mapping makes of it, and a stream that ends
+// without it gives ErrNoStopBit.
+func (s *InteropDecoder) Read(out []int) (int, error) {
+	if !s.opts.StopBit {
+		for cpos := range out {
+			v, _, err := s.next()
+			if err != nil {
+				return cpos, err
+			}
+			out[cpos] = v
+		}
+		return len(out), nil
+	}
+
+	for cpos := range out {
+		if s.stopped {
+			return cpos, io.EOF
+		}
+		if !s.haveAhead {
+			v, one, err := s.next()
+			if err == io.EOF {
+				err = ErrNoStopBit
+			}
+			if err != nil {
+				return cpos, err
+			}
+			s.ahead, s.aheadOne, s.haveAhead = v, one, true
+		}
+		if !s.aheadOne {
+			out[cpos] = s.ahead
+			s.haveAhead = false
+			continue
+		}
+		// A one-bit codeword is a value only if another follows it.
+		v, one, err := s.next()
+		if err == io.EOF {
+			s.stopped, s.haveAhead = true, false
+			return cpos, io.EOF
+		} else if err != nil {
+			return cpos, err
+		}
+		out[cpos] = s.ahead
+		s.ahead, s.aheadOne = v, one
+	}
+	return len(out), nil
+}
+
+// next decodes one value, and reports whether its codeword was the
+// single 1 bit that a stop bit looks like.  The sign mapping is done
+// here rather than by the decoder so that the codeword can be seen
+// before it is mapped:  in Positive mode it stands for 1, not 0.
+func (s *InteropDecoder) next() (int, bool, error) {
+	var n int
+	var err error
+	if s.opts.Unsigned || s.opts.Sign == ZigZag || s.opts.Sign == Positive {
+		if n, err = s.d.ReadUnsigned(s.utmp); n == 1 {
+			u := s.utmp[0]
+			switch {
+			case s.opts.Unsigned:
+				return int(u), u == 0, nil
+			case s.opts.Sign == ZigZag:
+				return unZigZag(u), u == 0, nil
+			case u >= math.MaxInt:
+				return 0, false, ErrOverflow
+			default:
+				return int(u) + 1, u == 0, nil
+			}
+		}
+	} else if n, err = s.d.Read(s.tmp); n == 1 {
+		// Only zero has no sign bit.
+		return s.tmp[0], s.tmp[0] == 0, nil
+	}
+	if err == io.EOF && s.d.truncated() {
+		err = io.ErrUnexpectedEOF
+	}
+	return 0, false, err
+}
+
+// reverseBitsReader mirrors the bits of every byte, turning an
+// LSB-first stream into the MSB-first order the decoder expects.
+type reverseBitsReader struct {
+	r byteReader
+}
+
+func (r *reverseBitsReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	return bits.Reverse8(b), err
+}
+
+func (r *reverseBitsReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	for i := range p[:n] {
+		p[i] = bits.Reverse8(p[i])
+	}
+	return n, err
+}
@@ -0,0 +1,80 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestInteropDecoder(t *testing.T) {
+	// Sample streams worked out bit by bit for each framing.
+	cases := []struct {
+		name   string
+		opts   InteropOptions
+		stream []byte
+		want   []int
+	}{
+		// 1 010 011 00100 0001000, stop bit, padding.
+		{"unsigned stop", InteropOptions{Unsigned: true, StopBit: true},
+			[]byte{0xa6, 0x41, 0x10}, []int{0, 1, 2, 3, 7}},
+		{"unsigned stop lsb", InteropOptions{Unsigned: true, StopBit: true, LSBFirst: true},
+			[]byte{0x65, 0x82, 0x08}, []int{0, 1, 2, 3, 7}},
+		// Zigzag 0, 1, 2, 3 are 0, -1, 1, -2.
+		{"zigzag stop", InteropOptions{Sign: ZigZag, StopBit: true},
+			[]byte{0xa6, 0x48}, []int{0, -1, 1, -2}},
+		// 00100 0, 010 1, 1:  this package's own format.
+		{"sign bit", InteropOptions{}, []byte{0x21, 0x60}, []int{3, -1, 0}},
+		// Two real zeros, then the stop bit.
+		{"zeros stop", InteropOptions{Unsigned: true, StopBit: true},
+			[]byte{0xe0}, []int{0, 0}},
+		{"empty stop", InteropOptions{StopBit: true}, []byte{0x80}, []int{}},
+		// 1 010, stop bit:  the one-bit codeword stands for 1 here.
+		{"positive stop", InteropOptions{Sign: Positive, StopBit: true},
+			[]byte{0xa8}, []int{1, 2}},
+		{"positive ones stop", InteropOptions{Sign: Positive, StopBit: true},
+			[]byte{0xe0}, []int{1, 1}},
+	}
+	for _, c := range cases {
+		// One value per Read, to exercise the held-back zero.
+		for _, size := range []int{1, 100} {
+			d := NewInteropDecoder(bytes.NewReader(c.stream), c.opts)
+			res := make([]int, 0)
+			out := make([]int, size)
+			for {
+				n, err := d.Read(out)
+				res = append(res, out[:n]...)
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("%s: %v", c.name, err)
+				}
+			}
+			if len(res) != len(c.want) {
+				t.Fatalf("%s: got %v, want %v", c.name, res, c.want)
+			}
+			for i := range res {
+				if res[i] != c.want[i] {
+					t.Fatalf("%s: got %v, want %v", c.name, res, c.want)
+				}
+			}
+		}
+	}
+
+	// 1 010 with no stop bit.
+	d := NewInteropDecoder(bytes.NewReader([]byte{0xa0}), InteropOptions{Unsigned: true, StopBit: true})
+	if n, err := d.Read(make([]int, 10)); n != 2 || err != ErrNoStopBit {
+		t.Fatalf("Missing stop bit gave %d, %v", n, err)
+	}
+
+	// MaxInt as an unsigned codeword is one past the largest Positive
+	// value.
+	buf := &bytes.Buffer{}
+	e := NewExpGolombEncoder(buf)
+	e.WriteUnsigned(math.MaxInt)
+	e.Close()
+	d = NewInteropDecoder(buf, InteropOptions{Sign: Positive})
+	if n, err := d.Read(make([]int, 1)); n != 0 || err != ErrOverflow {
+		t.Fatalf("Oversized Positive value gave %d, %v", n, err)
+	}
+}
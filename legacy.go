@@ -0,0 +1,59 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+)
+
+// LegacyDecode decodes compressed the way DeltaDecode did before the
+// decoder learned to read sign bits:  every codeword is taken as a
+// plain unsigned Exp-Golomb value, so a sign bit written by the
+// encoder is read as the start of the next codeword.  The values are
+// exactly those the old decoder returned, for callers who must keep
+// reproducing them.  A codeword cut off at the end, which the old
+// decoder dropped silently, is reported as io.ErrUnexpectedEOF along
+// with the values before it; a codeword too long for a uint gives
+// ErrOverflow.
+func LegacyDecode(base int, compressed []byte) ([]int, error) {
+	res := make([]int, 0)
+	val := base
+	decoder := NewExpGolombDecoder(bytes.NewReader(compressed))
+	tmp := make([]uint, 256)
+	for {
+		n, err := decoder.ReadUnsigned(tmp)
+		for _, d := range tmp[:n] {
+			val += int(d)
+			res = append(res, val)
+		}
+		if err == io.EOF && decoder.truncated() {
+			return res, io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+	}
+}
+
+// Migrate rewrites a stream so that the current decoder reads from it
+// the same values LegacyDecode does:  DeltaDecode(base, Migrate(old))
+// equals LegacyDecode(base, old) for every base.  A truncated final
+// codeword is dropped, as the old decoder dropped it.
+func Migrate(old []byte) []byte {
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	decoder := NewExpGolombDecoder(bytes.NewReader(old))
+	tmp := make([]uint, 256)
+	for {
+		n, err := decoder.ReadUnsigned(tmp)
+		for _, d := range tmp[:n] {
+			egs.WriteInt(int(d))
+		}
+		if err != nil {
+			break
+		}
+	}
+	egs.Close()
+	return buf.Bytes()
+}
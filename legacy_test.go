@@ -0,0 +1,71 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestLegacyDecode(t *testing.T) {
+	// Residuals 3, -1 are written 00100 0, 010 1.  The old decoder
+	// read 00100 as 3, then took the sign bit as the first zero of
+	// 00101, which is 4.
+	res, err := LegacyDecode(10, DeltaEncode(0, []int{3, 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{13, 17}
+	if len(res) != len(want) {
+		t.Fatalf("Got %v, want %v", res, want)
+	}
+	for i := range want {
+		if res[i] != want[i] {
+			t.Fatalf("Got %v, want %v", res, want)
+		}
+	}
+
+	// A stream the legacy reader handled correctly:  nondecreasing
+	// data written as unsigned residuals.
+	data := make([]int, 2000)
+	v := 50
+	for i := range data {
+		v += rand.Intn(300)
+		data[i] = v
+	}
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	prev := 50
+	for _, d := range data {
+		egs.WriteUnsigned(uint(d - prev))
+		prev = d
+	}
+	egs.Close()
+	old := buf.Bytes()
+
+	res, err = LegacyDecode(50, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrated := Migrate(old)
+	for _, got := range [][]int{res, DeltaDecode(50, migrated)} {
+		if len(got) != len(data) {
+			t.Fatalf("Got %d values, want %d", len(got), len(data))
+		}
+		for i := range data {
+			if got[i] != data[i] {
+				t.Fatalf("Item %d was %d, expected %d", i, got[i], data[i])
+			}
+		}
+	}
+
+	// Truncated: a long zero prefix with nothing after it.
+	if _, err := LegacyDecode(0, []byte{0x80, 0x00, 0x00}); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+	// A value of 1, then a 66-zero prefix.
+	res, err = LegacyDecode(0, []byte{0x40, 0, 0, 0, 0, 0, 0, 0, 0x04})
+	if err != ErrOverflow || len(res) != 1 {
+		t.Fatalf("Got %v, %v, want [1] and ErrOverflow", res, err)
+	}
+}
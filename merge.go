@@ -0,0 +1,71 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"container/heap"
+)
+
+// mergeSource is one input stream of MergeK together with the value
+// it is currently positioned on.
+type mergeSource struct {
+	decoder *ExpGolombDecoder
+	val     int
+}
+
+type mergeHeap []*mergeSource
+
+func (h mergeHeap) Len() int            { return len(h) }
+func (h mergeHeap) Less(i, j int) bool  { return h[i].val < h[j].val }
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeSource)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	src := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return src
+}
+
+// next advances src to its next value, reporting false at the end of
+// its stream.
+func (src *mergeSource) next(tmp []int) bool {
+	n, _ := src.decoder.Read(tmp[:1])
+	if n == 0 {
+		return false
+	}
+	src.val += tmp[0]
+	return true
+}
+
+// MergeK merges K sorted delta-coded streams, all encoded against
+// base, into one sorted stream against base with duplicates removed.
+// Each stream is decoded one value at a time, with a heap picking the
+// smallest current value, so no stream is ever fully decoded.
+func MergeK(base int, streams [][]byte) []byte {
+	tmp := make([]int, 1)
+	h := make(mergeHeap, 0, len(streams))
+	for _, s := range streams {
+		src := &mergeSource{NewExpGolombDecoder(bytes.NewReader(s)), base}
+		if src.next(tmp) {
+			h = append(h, src)
+		}
+	}
+	heap.Init(&h)
+
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	prev, first := base, true
+	for len(h) > 0 {
+		src := h[0]
+		if first || src.val != prev {
+			egs.WriteInt(src.val - prev)
+			prev, first = src.val, false
+		}
+		if src.next(tmp) {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	egs.Close()
+	return buf.Bytes()
+}
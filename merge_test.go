@@ -0,0 +1,47 @@
+package deltagolomb
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestMergeK(t *testing.T) {
+	base := -50
+	streams := make([][]byte, 0)
+	all := make([]int, 0)
+	for _, n := range []int{0, 1, 10, 300, 1000, 57} {
+		list := make([]int, n)
+		v := base + rand.Intn(20)
+		for i := range list {
+			v += rand.Intn(5) // includes duplicates within a stream
+			list[i] = v
+		}
+		streams = append(streams, DeltaEncode(base, list))
+		all = append(all, list...)
+	}
+	// The same list twice overlaps entirely.
+	streams = append(streams, streams[4])
+
+	sort.Ints(all)
+	want := make([]int, 0)
+	for i, v := range all {
+		if i == 0 || v != all[i-1] {
+			want = append(want, v)
+		}
+	}
+
+	res := DeltaDecode(base, MergeK(base, streams))
+	if len(res) != len(want) {
+		t.Fatalf("Got %d values, want %d", len(res), len(want))
+	}
+	for i := range want {
+		if res[i] != want[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], want[i])
+		}
+	}
+
+	if res := DeltaDecode(0, MergeK(0, nil)); len(res) != 0 {
+		t.Fatal("Merging no streams gave ", res)
+	}
+}
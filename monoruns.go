@@ -0,0 +1,120 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+var ErrNotSorted = errors.New("deltagolomb: values are not in nondecreasing order")
+
+// EncodeMonotonicRuns compresses a nondecreasing sequence, such as a
+// posting list with duplicates, as (gap, run length) pairs of unsigned
+// Exp-Golomb codewords:  one pair per distinct value, however often
+// it repeats.  The first gap is the zigzagged first value; later gaps
+// are at least one and are stored less one.  Run lengths are stored
+// less one as well.  Unsorted input gives ErrNotSorted, and a run
+// longer than 2^31 gives ErrRunLength.
+func EncodeMonotonicRuns(values []int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	for i := 0; i < len(values); {
+		j := i + 1
+		for j < len(values) && values[j] == values[i] {
+			j++
+		}
+		if j < len(values) && values[j] < values[i] {
+			return nil, ErrNotSorted
+		}
+		if j-i > 1<<31 {
+			return nil, ErrRunLength
+		}
+		if i == 0 {
+			egs.WriteUnsigned(zigZag(values[0]))
+		} else {
+			egs.WriteUnsigned(uint(values[i]-values[i-1]) - 1)
+		}
+		egs.WriteUnsigned(uint(j-i) - 1)
+		i = j
+	}
+	egs.Close()
+	return buf.Bytes(), nil
+}
+
+// DecodeMonotonicRuns reverses EncodeMonotonicRuns.  A gap with no
+// run length after it gives io.ErrUnexpectedEOF, and a run longer than
+// 2^31 gives ErrRunLength.
+func DecodeMonotonicRuns(compressed []byte) ([]int, error) {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(bytes.NewReader(compressed))
+	tmp := make([]uint, 1)
+	val := 0
+	for {
+		if n, err := decoder.ReadUnsigned(tmp); n == 0 {
+			if err == io.EOF && decoder.truncated() {
+				err = io.ErrUnexpectedEOF
+			}
+			if err == io.EOF {
+				return res, nil
+			}
+			return res, err
+		}
+		if len(res) == 0 {
+			val = unZigZag(tmp[0])
+		} else {
+			val += int(tmp[0]) + 1
+		}
+
+		if n, err := decoder.ReadUnsigned(tmp); n == 0 {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return res, err
+		}
+		if tmp[0] >= 1<<31 {
+			return res, ErrRunLength
+		}
+		for run := tmp[0] + 1; run > 0; run-- {
+			res = append(res, val)
+		}
+	}
+}
+
+// DecodeSortedDedup decodes a delta-coded stream of nondecreasing
+// values, as written by DeltaEncode, dropping repeats as it goes:  a
+// zero gap appends nothing, so the result is strictly increasing.
+// The first value is always kept, whatever its distance from base.
+// A negative gap after it gives ErrNotSorted along with the values
+// decoded so far.
+func DecodeSortedDedup(base int, compressed []byte) ([]int, error) {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(bytes.NewReader(compressed))
+	tmp := make([]int, 256)
+	val := base
+	for {
+		n, err := decoder.Read(tmp)
+		for _, gap := range tmp[:n] {
+			if len(res) == 0 {
+				val += gap
+				res = append(res, val)
+				continue
+			}
+			if gap < 0 {
+				return res, ErrNotSorted
+			}
+			if gap > 0 {
+				val += gap
+				res = append(res, val)
+			}
+		}
+		if err == io.EOF && decoder.truncated() {
+			return res, io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+}
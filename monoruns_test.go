@@ -0,0 +1,104 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeMonotonicRuns(t *testing.T) {
+	values := []int{-3}
+	v := -3
+	for i := 0; i < 200; i++ {
+		v += rand.Intn(50)
+		for run := 50 + rand.Intn(200); run >= 0; run-- {
+			values = append(values, v)
+		}
+	}
+
+	compressed, err := EncodeMonotonicRuns(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := DecodeMonotonicRuns(compressed)
+	if err != nil {
+		t.Fatal("DecodeMonotonicRuns failed: ", err)
+	}
+	if len(res) != len(values) {
+		t.Fatalf("Got %d values, want %d", len(res), len(values))
+	}
+	for i := range values {
+		if res[i] != values[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], values[i])
+		}
+	}
+
+	plain := DeltaEncode(0, values)
+	if len(compressed) >= len(plain)/2 {
+		t.Errorf("Run coding took %d bytes, plain gaps %d", len(compressed), len(plain))
+	}
+
+	if _, err := EncodeMonotonicRuns([]int{1, 2, 2, 1}); err != ErrNotSorted {
+		t.Fatal("Expected ErrNotSorted, got ", err)
+	}
+	if res, err := DecodeMonotonicRuns(nil); err != nil || len(res) != 0 {
+		t.Fatalf("Empty input gave %v, %v", res, err)
+	}
+	// A first value of zero with its run length missing.
+	if _, err := DecodeMonotonicRuns([]byte{0x80}); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+	// Value 3 twice, then value 4 claiming a run of 2^62.
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	egs.WriteUnsigned(zigZag(3))
+	egs.WriteUnsigned(1)
+	egs.WriteUnsigned(0)
+	egs.WriteUnsigned(1<<62 - 1)
+	egs.Close()
+	if res, err := DecodeMonotonicRuns(buf.Bytes()); err != ErrRunLength || len(res) != 2 {
+		t.Fatalf("Oversized run gave %v, %v", res, err)
+	}
+}
+
+func TestDecodeSortedDedup(t *testing.T) {
+	values := make([]int, 0)
+	want := make([]int, 0)
+	v := -40
+	for i := 0; i < 2000; i++ {
+		v += rand.Intn(20) + 1
+		want = append(want, v)
+		for dup := rand.Intn(4) - 1; dup >= 0; dup-- {
+			values = append(values, v)
+		}
+		values = append(values, v)
+	}
+
+	res, err := DecodeSortedDedup(5, DeltaEncode(5, values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(want) {
+		t.Fatalf("Got %d values, want %d", len(res), len(want))
+	}
+	for i := range want {
+		if res[i] != want[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], want[i])
+		}
+		if i > 0 && res[i] <= res[i-1] {
+			t.Fatalf("Item %d (%d) does not follow %d", i, res[i], res[i-1])
+		}
+	}
+
+	// A first value equal to base is kept.
+	if res, err := DecodeSortedDedup(7, DeltaEncode(7, []int{7, 7, 8})); err != nil || len(res) != 2 || res[0] != 7 || res[1] != 8 {
+		t.Fatal("Got ", res, err)
+	}
+	if res, err := DecodeSortedDedup(0, DeltaEncode(0, []int{1, 3, 2})); err != ErrNotSorted || len(res) != 2 {
+		t.Fatal("Expected ErrNotSorted after two values, got ", res, err)
+	}
+	if _, err := DecodeSortedDedup(0, []byte{0x01}); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+}
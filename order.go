@@ -0,0 +1,88 @@
+package deltagolomb
+
+import (
+	"bytes"
+)
+
+// Order k leaves u = |v| + 2^k at no more than 64 bits.
+const maxOrder = 62
+
+// writeOrderK writes item as an order-k Exp-Golomb codeword with the
+// package's sign bit:  u = |item| + 2^k in binary, behind one zero
+// for each bit of u beyond the k+1 low ones, then the sign if item is
+// nonzero.  Order 0 is the ordinary codeword.
+func writeOrderK(egs *ExpGolombEncoder, k uint, item int) {
+	m := uint64(item)
+	if item < 0 {
+		m = -m
+	}
+	u := m + 1<<k
+	n := uint(bitLen(uint(u)))
+	egs.WriteRawBits(0, n-1-k)
+	egs.WriteRawBits(u, n)
+	if item < 0 {
+		egs.WriteRawBits(1, 1)
+	} else if item > 0 {
+		egs.WriteRawBits(0, 1)
+	}
+}
+
+// readOrderK reads one codeword written by writeOrderK.  ok is false
+// at the end of the stream, including its zero padding, and for a
+// codeword that is cut off or too long to be valid.
+func readOrderK(d *ExpGolombDecoder, k uint) (item int, ok bool) {
+	zeros := uint(0)
+	for {
+		bit, err := d.ReadRawBits(1)
+		if err != nil {
+			return 0, false
+		}
+		if bit == 1 {
+			break
+		}
+		zeros++
+		if zeros+k >= 64 {
+			return 0, false
+		}
+	}
+	rest, err := d.ReadRawBits(zeros + k)
+	if err != nil {
+		return 0, false
+	}
+	m := (1<<(zeros+k) | rest) - 1<<k
+	if m == 0 {
+		return 0, true
+	}
+	sign, err := d.ReadRawBits(1)
+	if err != nil {
+		return 0, false
+	}
+	if sign == 1 {
+		return int(-m), true
+	}
+	return int(m), true
+}
+
+// ConvertOrder re-codes a stream of signed order-fromK Exp-Golomb
+// codewords as order-toK codewords, one value at a time, without
+// collecting the values.  Larger orders spend k more bits on small
+// values and fewer on large ones.  Conversion stops at the end of
+// src or at the first codeword that is cut off or malformed; values
+// before it are kept.  Orders above 62 give nil.
+func ConvertOrder(src []byte, fromK, toK uint) []byte {
+	if fromK > maxOrder || toK > maxOrder {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	decoder := NewExpGolombDecoder(bytes.NewReader(src))
+	for {
+		v, ok := readOrderK(decoder, fromK)
+		if !ok {
+			break
+		}
+		writeOrderK(egs, toK, v)
+	}
+	egs.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,56 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func decodeOrder(compressed []byte, k uint) []int {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(bytes.NewReader(compressed))
+	for {
+		v, ok := readOrderK(decoder, k)
+		if !ok {
+			return res
+		}
+		res = append(res, v)
+	}
+}
+
+func TestConvertOrder(t *testing.T) {
+	values := []int{0, 1, -1, 3, 1<<63 - 1, -1 << 63, 1 << 40}
+	for i := 0; i < 1000; i++ {
+		values = append(values, rand.Intn(2001)-1000)
+	}
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	egs.Write(values)
+	egs.Close()
+	src := buf.Bytes()
+
+	if !bytes.Equal(ConvertOrder(src, 0, 0), src) {
+		t.Fatal("Order 0 to 0 changed the stream")
+	}
+	prev, prevK := src, uint(0)
+	for _, k := range []uint{3, 1, 8, 62, 5, 0} {
+		converted := ConvertOrder(prev, prevK, k)
+		got := decodeOrder(converted, k)
+		if len(got) != len(values) {
+			t.Fatalf("Order %d to %d: got %d values, want %d", prevK, k, len(got), len(values))
+		}
+		for i := range values {
+			if got[i] != values[i] {
+				t.Fatalf("Order %d to %d: item %d was %d, expected %d", prevK, k, i, got[i], values[i])
+			}
+		}
+		prev, prevK = converted, k
+	}
+	if !bytes.Equal(prev, src) {
+		t.Fatal("Converting back to order 0 did not restore the stream")
+	}
+
+	if ConvertOrder(src, 0, 63) != nil {
+		t.Fatal("Expected nil for order 63")
+	}
+}
@@ -0,0 +1,83 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var ErrPairLength = errors.New("deltagolomb: keys and values differ in length")
+
+// EncodePairs writes (key, value) pairs as two columns, each coded to
+// suit the usual shape of its data:  the keys, such as timestamps,
+// delta-coded against the first key in a frame as EncodeFramed writes
+// it, then the values, such as noisy measurements, coded directly as
+// zigzagged unsigned codewords in a frame of their own, its length as
+// an unsigned varint followed by the bytes.
+func EncodePairs(w io.Writer, keys, values []int) error {
+	if len(keys) != len(values) {
+		return ErrPairLength
+	}
+	if err := EncodeFramed(w, []int{ChooseBase(keys)}, [][]int{keys}); err != nil {
+		return err
+	}
+	frame := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(frame)
+	for _, v := range values {
+		egs.WriteUnsigned(zigZag(v))
+	}
+	egs.Close()
+	var hdr [binary.MaxVarintLen64]byte
+	if _, err := w.Write(hdr[:binary.PutUvarint(hdr[:], uint64(frame.Len()))]); err != nil {
+		return err
+	}
+	_, err := w.Write(frame.Bytes())
+	return err
+}
+
+// DecodePairs reverses EncodePairs.  A stream that ends early, or a
+// value frame that ends partway through a codeword, gives
+// io.ErrUnexpectedEOF; errors decoding the values, such as
+// ErrOverflow, are returned as they are; and columns that decode to
+// different lengths give ErrPairLength.
+func DecodePairs(r io.Reader) (keys, values []int, err error) {
+	br := makeReader(r)
+	base, err := binary.ReadVarint(br)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	frame, err := readFrame(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys = DeltaDecode(int(base), frame)
+
+	if frame, err = readFrame(br); err != nil {
+		return nil, nil, err
+	}
+	values = make([]int, 0, len(keys))
+	decoder := NewExpGolombDecoder(bytes.NewReader(frame))
+	tmp := make([]uint, 256)
+	for {
+		n, err := decoder.ReadUnsigned(tmp)
+		for _, u := range tmp[:n] {
+			values = append(values, unZigZag(u))
+		}
+		if err == io.EOF && decoder.truncated() {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(values) != len(keys) {
+		return nil, nil, ErrPairLength
+	}
+	return keys, values, nil
+}
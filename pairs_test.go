@@ -0,0 +1,85 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodePairs(t *testing.T) {
+	// A sensor sampled about once a second, reading around 20 with
+	// noise.
+	keys := make([]int, 2000)
+	values := make([]int, len(keys))
+	ts := 1700000000
+	for i := range keys {
+		ts += 1 + rand.Intn(3) - 1
+		keys[i] = ts
+		values[i] = 20 + rand.Intn(401) - 200
+	}
+
+	buf := &bytes.Buffer{}
+	if err := EncodePairs(buf, keys, values); err != nil {
+		t.Fatal(err)
+	}
+	stream := buf.Bytes()
+	gotKeys, gotValues, err := DecodePairs(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotKeys) != len(keys) || len(gotValues) != len(values) {
+		t.Fatalf("Got %d keys and %d values, want %d", len(gotKeys), len(gotValues), len(keys))
+	}
+	for i := range keys {
+		if gotKeys[i] != keys[i] || gotValues[i] != values[i] {
+			t.Fatalf("Pair %d was (%d, %d), expected (%d, %d)", i, gotKeys[i], gotValues[i], keys[i], values[i])
+		}
+	}
+
+	// The steady keys cost a few bits each, the noisy values many.
+	r := bytes.NewReader(stream)
+	binary.ReadVarint(r)
+	keySize, _ := binary.ReadUvarint(r)
+	r.Seek(int64(keySize), io.SeekCurrent)
+	valueSize, _ := binary.ReadUvarint(r)
+	if 8*keySize > 4*uint64(len(keys)) || valueSize < 3*keySize {
+		t.Fatalf("Keys took %d bytes, values %d", keySize, valueSize)
+	}
+
+	if _, _, err := DecodePairs(bytes.NewReader(stream[:len(stream)-1])); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+	if err := EncodePairs(buf, keys, values[1:]); err != ErrPairLength {
+		t.Fatal("Expected ErrPairLength, got ", err)
+	}
+	buf.Reset()
+	EncodePairs(buf, nil, nil)
+	if k, v, err := DecodePairs(buf); err != nil || len(k) != 0 || len(v) != 0 {
+		t.Fatal("Empty pairs gave ", k, v, err)
+	}
+}
+
+func TestDecodePairsBadValues(t *testing.T) {
+	cases := []struct {
+		name  string
+		frame []byte
+		want  error
+	}{
+		// Zigzag 0 and 1, then a codeword cut off after its prefix.
+		{"truncated", []byte{0xa0, 0x00}, io.ErrUnexpectedEOF},
+		// A 66-zero prefix.
+		{"overflow", []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x20}, ErrOverflow},
+	}
+	for _, c := range cases {
+		buf := &bytes.Buffer{}
+		EncodeFramed(buf, []int{0}, [][]int{{1, 2, 3}})
+		var hdr [binary.MaxVarintLen64]byte
+		buf.Write(hdr[:binary.PutUvarint(hdr[:], uint64(len(c.frame)))])
+		buf.Write(c.frame)
+		if _, _, err := DecodePairs(buf); err != c.want {
+			t.Fatalf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+var ErrPlanarCount = errors.New("deltagolomb: implausible planar value count")
+
+// EncodePlanar codes values with magnitudes and signs in separate
+// planes:  the value count as an unsigned codeword, every magnitude
+// as an unsigned codeword, then one sign bit per nonzero value,
+// 1 for negative.  Decoding the magnitudes needs no per-value sign
+// handling.
+func EncodePlanar(w io.Writer, values []int) error {
+	egs := NewExpGolombEncoder(w)
+	egs.WriteUnsigned(uint(len(values)))
+	for _, v := range values {
+		if v < 0 {
+			egs.WriteUnsigned(uint(-v))
+		} else {
+			egs.WriteUnsigned(uint(v))
+		}
+	}
+	for _, v := range values {
+		if v < 0 {
+			egs.addBits(1, 1)
+		} else if v > 0 {
+			egs.addBits(0, 1)
+		}
+	}
+	return egs.Close()
+}
+
+// DecodePlanar reverses EncodePlanar.  A stream that ends before
+// every magnitude and sign has been read gives io.ErrUnexpectedEOF,
+// and a count above 2^31 gives ErrPlanarCount.
+func DecodePlanar(r io.Reader) ([]int, error) {
+	decoder := NewExpGolombDecoder(r)
+	hdr := make([]uint, 1)
+	if n, err := decoder.ReadUnsigned(hdr); n == 0 {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if hdr[0] > 1<<31 {
+		return nil, ErrPlanarCount
+	}
+
+	mags, err := readN(hdr[0], decoder.ReadUnsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]int, len(mags))
+	for i, m := range mags {
+		res[i] = int(m)
+		if m == 0 {
+			continue
+		}
+		sign, err := decoder.readBit()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if sign == 1 {
+			res[i] = -res[i]
+		}
+	}
+	return res, nil
+}
+
+// DecodeSplit decodes a stream of sign-bit codewords, such as
+// DeltaEncode's residuals, into what each codeword stores:  the
+// magnitude, and beside it whether the sign bit said negative.  Zero
+// has no sign bit and is reported as not negative.  The one magnitude
+// too large for an int, 2^63, comes back as -1 << 63.  A codeword cut
+// off at the end gives io.ErrUnexpectedEOF along with those before
+// it.
+func DecodeSplit(compressed []byte) (magnitudes []int, signs []bool, err error) {
+	magnitudes = make([]int, 0)
+	signs = make([]bool, 0)
+	decoder := NewExpGolombDecoder(bytes.NewReader(compressed))
+	tmp := make([]uint, 1)
+	for {
+		if n, err := decoder.ReadUnsigned(tmp); n == 0 {
+			if err == io.EOF && decoder.truncated() {
+				err = io.ErrUnexpectedEOF
+			}
+			if err == io.EOF {
+				err = nil
+			}
+			return magnitudes, signs, err
+		}
+		negative := false
+		if tmp[0] != 0 {
+			sign, err := decoder.readBit()
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return magnitudes, signs, err
+			}
+			negative = sign == 1
+		}
+		magnitudes = append(magnitudes, int(tmp[0]))
+		signs = append(signs, negative)
+	}
+}
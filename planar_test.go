@@ -0,0 +1,127 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodePlanar(t *testing.T) {
+	values := []int{0, 5, -5, 1, -1, 0, 1 << 40, -(1 << 40), -1 << 63, 1<<63 - 1}
+	for i := 0; i < 1000; i++ {
+		values = append(values, rand.Intn(2001)-1000)
+	}
+	buf := &bytes.Buffer{}
+	if err := EncodePlanar(buf, values); err != nil {
+		t.Fatal(err)
+	}
+	stream := buf.Bytes()
+	res, err := DecodePlanar(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal("DecodePlanar failed: ", err)
+	}
+	if len(res) != len(values) {
+		t.Fatalf("Got %d values, want %d", len(res), len(values))
+	}
+	for i := range values {
+		if res[i] != values[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], values[i])
+		}
+	}
+
+	if _, err := DecodePlanar(bytes.NewReader(stream[:len(stream)-1])); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+	if res, err := DecodePlanar(bytes.NewReader([]byte{0x80})); err != nil || len(res) != 0 {
+		t.Fatalf("Empty stream gave %v, %v", res, err)
+	}
+	// Counts the data cannot back must fail without allocating them.
+	for _, count := range []uint{1 << 31, 1 << 62} {
+		buf := &bytes.Buffer{}
+		e := NewExpGolombEncoder(buf)
+		e.WriteUnsigned(count)
+		e.WriteUnsigned(3)
+		e.Close()
+		want := io.ErrUnexpectedEOF
+		if count > 1<<31 {
+			want = ErrPlanarCount
+		}
+		if _, err := DecodePlanar(buf); err != want {
+			t.Fatalf("Count %d: got %v, want %v", count, err, want)
+		}
+	}
+}
+
+func TestDecodeSplit(t *testing.T) {
+	values := []int{0, 5, -5, 1, -1, 0, 1 << 40, -(1 << 40), -1 << 63, 1<<63 - 1}
+	for i := 0; i < 1000; i++ {
+		values = append(values, rand.Intn(2001)-1000)
+	}
+	compressed := DeltaEncode(0, values)
+	residuals := DecodeResiduals(compressed)
+
+	mags, signs, err := DecodeSplit(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mags) != len(residuals) || len(signs) != len(residuals) {
+		t.Fatalf("Got %d magnitudes and %d signs, want %d", len(mags), len(signs), len(residuals))
+	}
+	for i, r := range residuals {
+		v := mags[i]
+		if signs[i] {
+			v = -v
+		}
+		if v != r {
+			t.Fatalf("Item %d recombined to %d, expected %d", i, v, r)
+		}
+		if mags[i] < 0 && mags[i] != -1<<63 {
+			t.Fatalf("Item %d has magnitude %d", i, mags[i])
+		}
+		if mags[i] == 0 && signs[i] {
+			t.Fatalf("Item %d is a negative zero", i)
+		}
+	}
+
+	// 0, then 7 with its sign bit cut off.
+	if _, _, err := DecodeSplit([]byte{0x88}); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+}
+
+var planarBenchVals = func() []int {
+	vals := make([]int, 1000)
+	r := rand.New(rand.NewSource(1))
+	for i := range vals {
+		vals[i] = r.Intn(201) - 100
+	}
+	return vals
+}()
+
+// Compare with BenchmarkInterleavedDecode.
+func BenchmarkPlanarDecode(b *testing.B) {
+	buf := &bytes.Buffer{}
+	EncodePlanar(buf, planarBenchVals)
+	stream := buf.Bytes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DecodePlanar(bytes.NewReader(stream))
+	}
+}
+
+func BenchmarkInterleavedDecode(b *testing.B) {
+	stream := DeltaEncode(0, Integrate(0, planarBenchVals))
+	out := make([]int, len(planarBenchVals))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewExpGolombDecoder(bytes.NewReader(stream)).Read(out)
+	}
+}
+
+func BenchmarkPlanarEncode(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		EncodePlanar(ioutil.Discard, planarBenchVals)
+	}
+}
@@ -0,0 +1,104 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+const maxPolyDegree = 16
+
+var ErrDegree = errors.New("deltagolomb: polynomial degree must be between 0 and 16")
+
+// polyPredict extrapolates the polynomial of degree up to degree
+// through the values before prev[len(prev)], using the binomial
+// weights of the finite difference of one order higher.  Near the
+// start of the data, where fewer values are known, the order drops
+// to fit.  Arithmetic wraps identically on both sides, so prediction
+// is exact even when it overflows.
+func polyPredict(prev []int, degree int, weights [][]int) int {
+	k := degree + 1
+	if len(prev) < k {
+		k = len(prev)
+	}
+	pred := 0
+	for j, w := range weights[k] {
+		pred += w * prev[len(prev)-1-j]
+	}
+	return pred
+}
+
+// polyWeights returns, for each order k up to degree+1, the weights
+// that predict a value from the k before it, most recent first:
+// (-1)^(j+1) * C(k, j) for j = 1..k.
+func polyWeights(degree int) [][]int {
+	weights := make([][]int, degree+2)
+	for k := range weights {
+		weights[k] = make([]int, k)
+		c := 1
+		for j := 1; j <= k; j++ {
+			c = c * (k - j + 1) / j
+			if j%2 == 1 {
+				weights[k][j-1] = c
+			} else {
+				weights[k][j-1] = -c
+			}
+		}
+	}
+	return weights
+}
+
+// EncodePoly codes data as residuals from a polynomial predictor:
+// each value is predicted by extrapolating the polynomial of the
+// given degree through the degree+1 values before it, and only the
+// difference is Exp-Golomb coded.  Degree 0 is plain delta coding
+// and degree 1 linear extrapolation; a smooth signal that is locally
+// close to a polynomial of the degree codes to residuals near zero.
+// The predictor refits at every value from the data itself, so no
+// fit parameters are stored:  the stream is the degree as an
+// unsigned codeword followed by the residuals.
+func EncodePoly(w io.Writer, degree int, data []int) error {
+	if degree < 0 || degree > maxPolyDegree {
+		return ErrDegree
+	}
+	weights := polyWeights(degree)
+	egs := NewExpGolombEncoder(w)
+	egs.WriteUnsigned(uint(degree))
+	for i, v := range data {
+		egs.WriteInt(v - polyPredict(data[:i], degree, weights))
+	}
+	return egs.Close()
+}
+
+// DecodePoly reverses EncodePoly.
+func DecodePoly(r io.Reader) ([]int, error) {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(r)
+	hdr := make([]uint, 1)
+	if n, err := decoder.ReadUnsigned(hdr); n == 0 {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return res, err
+	}
+	if hdr[0] > maxPolyDegree {
+		return res, ErrDegree
+	}
+	degree := int(hdr[0])
+	weights := polyWeights(degree)
+
+	tmp := make([]int, 256)
+	for {
+		n, err := decoder.Read(tmp)
+		for _, d := range tmp[:n] {
+			res = append(res, polyPredict(res, degree, weights)+d)
+		}
+		if err == io.EOF && decoder.truncated() {
+			return res, io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestPolyWeights(t *testing.T) {
+	w := polyWeights(2)
+	want := [][]int{{}, {1}, {2, -1}, {3, -3, 1}}
+	for k := range want {
+		for j := range want[k] {
+			if w[k][j] != want[k][j] {
+				t.Fatalf("Weights %v, want %v", w, want)
+			}
+		}
+	}
+}
+
+func TestEncodePoly(t *testing.T) {
+	// A smooth quadratic, as of temperature over a day.
+	quad := make([]int, 2000)
+	for i := range quad {
+		x := i - 1000
+		quad[i] = 250000 - x*x + 3*x
+	}
+	noisy := make([]int, 2000)
+	for i := range noisy {
+		noisy[i] = rand.Int()>>1 - rand.Int()>>1
+	}
+
+	for _, data := range [][]int{quad, noisy, {}, {5}} {
+		for degree := 0; degree <= 4; degree++ {
+			buf := &bytes.Buffer{}
+			if err := EncodePoly(buf, degree, data); err != nil {
+				t.Fatal(err)
+			}
+			res, err := DecodePoly(buf)
+			if err != nil {
+				t.Fatalf("Degree %d: %v", degree, err)
+			}
+			if len(res) != len(data) {
+				t.Fatalf("Degree %d: got %d values, want %d", degree, len(res), len(data))
+			}
+			for i := range data {
+				if res[i] != data[i] {
+					t.Fatalf("Degree %d: item %d was %d, expected %d", degree, i, res[i], data[i])
+				}
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	EncodePoly(buf, 2, quad)
+	plain := DeltaEncode(0, quad)
+	if buf.Len()*5 > len(plain) {
+		t.Errorf("Degree 2 took %d bytes, plain delta %d", buf.Len(), len(plain))
+	}
+
+	if err := EncodePoly(buf, -1, quad); err != ErrDegree {
+		t.Fatal("Expected ErrDegree, got ", err)
+	}
+}
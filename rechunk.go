@@ -0,0 +1,73 @@
+package deltagolomb
+
+// ReChunk re-packs a delta-coded stream into self-contained blocks of
+// at most maxBytes bytes each.  Blocks are only split between values,
+// never inside a codeword.  Block i decodes on its own with
+// DeltaDecode(bases[i], blocks[i]); each base is the last value of
+// the block before it.  A value whose codeword alone needs more than
+// maxBytes bytes is given a block of its own, which will be larger
+// than maxBytes.
+func ReChunk(compressed []byte, base int, maxBytes int) (blocks [][]byte, bases []int) {
+	values := DeltaDecode(base, compressed)
+	blocks = make([][]byte, 0)
+	bases = make([]int, 0)
+
+	maxBits := 8 * maxBytes
+	blockStart, blockBase := 0, base
+	nbits, prev := 0, base
+	for i, v := range values {
+		l := codeLen(v - prev)
+		if nbits > 0 && nbits+l > maxBits {
+			blocks = append(blocks, DeltaEncode(blockBase, values[blockStart:i]))
+			bases = append(bases, blockBase)
+			blockStart, blockBase = i, prev
+			nbits = 0
+		}
+		nbits += l
+		prev = v
+	}
+	if blockStart < len(values) {
+		blocks = append(blocks, DeltaEncode(blockBase, values[blockStart:]))
+		bases = append(bases, blockBase)
+	}
+	return blocks, bases
+}
+
+// SplitAt splits a delta-coded stream into two streams that decode
+// independently:  first holds the values before index and second the
+// values from index on.  firstBase is base; secondBase is the last
+// value of the first half, or base if it is empty.  An index past the
+// end of the stream leaves second empty.
+func SplitAt(base int, compressed []byte, index int) (firstBase int, first []byte, secondBase int, second []byte) {
+	values := DeltaDecode(base, compressed)
+	if index < 0 {
+		index = 0
+	}
+	if index > len(values) {
+		index = len(values)
+	}
+	secondBase = base
+	if index > 0 {
+		secondBase = values[index-1]
+	}
+	return base, DeltaEncode(base, values[:index]), secondBase, DeltaEncode(secondBase, values[index:])
+}
+
+// EncodeWithin delta-encodes as many leading values of data as fit in
+// maxBytes bytes, padding included, and returns the encoding along
+// with how many values it holds.  A value whose codeword would cross
+// the budget is left out entirely, as is everything after it.
+func EncodeWithin(maxBytes int, start int, data []int) (encoded []byte, consumed int) {
+	maxBits := 8 * maxBytes
+	nbits, prev := 0, start
+	for _, v := range data {
+		l := codeLen(v - prev)
+		if nbits+l > maxBits {
+			break
+		}
+		nbits += l
+		prev = v
+		consumed++
+	}
+	return DeltaEncode(start, data[:consumed]), consumed
+}
@@ -0,0 +1,114 @@
+package deltagolomb
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestReChunk(t *testing.T) {
+	data := make([]int, 3000)
+	v := 0
+	for i := range data {
+		v += rand.Intn(2001) - 1000
+		data[i] = v
+	}
+	base := 77
+	compressed := DeltaEncode(base, data)
+
+	for _, maxBytes := range []int{1, 2, 5, 64, 1 << 20} {
+		blocks, bases := ReChunk(compressed, base, maxBytes)
+		if len(blocks) != len(bases) {
+			t.Fatalf("maxBytes %d: %d blocks but %d bases", maxBytes, len(blocks), len(bases))
+		}
+		all := make([]int, 0)
+		for i, b := range blocks {
+			// With maxBytes 1 some codewords cannot fit; those get a
+			// block to themselves.
+			d := DeltaDecode(bases[i], b)
+			if len(b) > maxBytes && len(d) != 1 {
+				t.Fatalf("maxBytes %d: block %d is %d bytes with %d values", maxBytes, i, len(b), len(d))
+			}
+			all = append(all, d...)
+		}
+		if len(all) != len(data) {
+			t.Fatalf("maxBytes %d: got %d values, want %d", maxBytes, len(all), len(data))
+		}
+		for i := range data {
+			if all[i] != data[i] {
+				t.Fatalf("maxBytes %d: item %d was %d, expected %d", maxBytes, i, all[i], data[i])
+			}
+		}
+	}
+
+	if blocks, bases := ReChunk(nil, base, 8); len(blocks) != 0 || len(bases) != 0 {
+		t.Fatal("Expected no blocks for an empty stream")
+	}
+}
+
+func TestSplitAt(t *testing.T) {
+	data := []int{5, 9, -3, -3, 400, 12, 0}
+	base := 2
+	compressed := DeltaEncode(base, data)
+	for index := -1; index <= len(data)+1; index++ {
+		firstBase, first, secondBase, second := SplitAt(base, compressed, index)
+		if firstBase != base {
+			t.Fatalf("Index %d: first base %d, want %d", index, firstBase, base)
+		}
+		head := DeltaDecode(firstBase, first)
+		tail := DeltaDecode(secondBase, second)
+		want := index
+		if want < 0 {
+			want = 0
+		} else if want > len(data) {
+			want = len(data)
+		}
+		if len(head) != want {
+			t.Fatalf("Index %d: first half has %d values, want %d", index, len(head), want)
+		}
+		all := append(head, tail...)
+		if len(all) != len(data) {
+			t.Fatalf("Index %d: got %d values, want %d", index, len(all), len(data))
+		}
+		for i := range data {
+			if all[i] != data[i] {
+				t.Fatalf("Index %d: item %d was %d, expected %d", index, i, all[i], data[i])
+			}
+		}
+	}
+}
+
+func TestEncodeWithin(t *testing.T) {
+	data := make([]int, 500)
+	v := 0
+	for i := range data {
+		v += rand.Intn(2001) - 1000
+		data[i] = v
+	}
+	// Value 1 sits at the start; its residual is 1 and takes 4 bits.
+	data[0] = 1
+	for _, maxBytes := range []int{0, 1, 2, 3, 17, 100, 1 << 20} {
+		encoded, consumed := EncodeWithin(maxBytes, 0, data)
+		if len(encoded) > maxBytes {
+			t.Fatalf("maxBytes %d: encoded %d bytes", maxBytes, len(encoded))
+		}
+		res := DeltaDecode(0, encoded)
+		if len(res) != consumed {
+			t.Fatalf("maxBytes %d: %d values decoded but %d consumed", maxBytes, len(res), consumed)
+		}
+		for i := range res {
+			if res[i] != data[i] {
+				t.Fatalf("maxBytes %d: item %d was %d, expected %d", maxBytes, i, res[i], data[i])
+			}
+		}
+		// The next value must genuinely not have fit.
+		if consumed < len(data) {
+			more := DeltaEncode(0, data[:consumed+1])
+			if len(more) <= maxBytes {
+				t.Fatalf("maxBytes %d: value %d would have fit", maxBytes, consumed)
+			}
+		}
+	}
+	if _, consumed := EncodeWithin(1, 0, data); consumed != 1 {
+		t.Fatalf("One byte should hold one value, got %d", consumed)
+	}
+}
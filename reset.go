@@ -0,0 +1,116 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+// No int needs more than 63 prefix zeros, so 64 can mark a reset.
+const resetEscape = 64
+
+var ErrResetMarker = errors.New("deltagolomb: codeword prefix longer than a reset marker")
+
+// ResetEncoder delta-codes values like DeltaEncode, but Reset can
+// re-seed the predictor at any point.  A reset is written in-band as
+// 64 zeros, a one, and the new base as 64 raw bits, a prefix no
+// ordinary codeword uses.  Decoding can start afresh at any reset,
+// since nothing after it depends on what came before.  Between resets
+// the stream is the same as DeltaEncode's.
+type ResetEncoder struct {
+	e    *ExpGolombEncoder
+	prev int
+}
+
+// Create a new ResetEncoder writing to w, with the first value coded
+// against base.  Users must call Close when finished.
+func NewResetEncoder(w io.Writer, base int) *ResetEncoder {
+	return &ResetEncoder{e: NewExpGolombEncoder(w), prev: base}
+}
+
+// Encode a slice of values as deltas from the one before.
+func (s *ResetEncoder) Write(values []int) {
+	for _, v := range values {
+		s.e.WriteInt(v - s.prev)
+		s.prev = v
+	}
+}
+
+// Encode a single value as the delta from the one before.
+func (s *ResetEncoder) WriteInt(v int) {
+	s.e.WriteInt(v - s.prev)
+	s.prev = v
+}
+
+// Reset writes a reset marker carrying newBase; the next value is
+// coded against newBase.
+func (s *ResetEncoder) Reset(newBase int) {
+	s.e.WriteRawBits(0, resetEscape)
+	s.e.WriteRawBits(1, 1)
+	s.e.WriteRawBits(uint64(newBase), 64)
+	s.prev = newBase
+}
+
+// Close pads and flushes the stream, as ExpGolombEncoder.Close does.
+func (s *ResetEncoder) Close() error {
+	return s.e.Close()
+}
+
+// DecodeWithResets reverses ResetEncoder, starting from base and
+// re-basing at each reset marker.  A stream that ends inside a
+// codeword or marker gives io.ErrUnexpectedEOF along with the values
+// decoded before it.
+func DecodeWithResets(r io.Reader, base int) ([]int, error) {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(r)
+	read := func(n uint) (uint64, error) {
+		v, err := decoder.ReadRawBits(n)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return v, err
+	}
+
+	prev := base
+	for {
+		zeros := uint(0)
+		for {
+			bit, err := decoder.readBit()
+			if err == io.EOF && zeros < 8 {
+				return res, nil // padding
+			} else if err == io.EOF {
+				return res, io.ErrUnexpectedEOF
+			} else if err != nil {
+				return res, err
+			}
+			if bit == 1 {
+				break
+			}
+			zeros++
+			if zeros > resetEscape {
+				return res, ErrResetMarker
+			}
+		}
+
+		switch {
+		case zeros == 0:
+			res = append(res, prev)
+		case zeros == resetEscape:
+			raw, err := read(64)
+			if err != nil {
+				return res, err
+			}
+			prev = int(raw)
+		default:
+			mag, err := read(zeros + 1)
+			if err != nil {
+				return res, err
+			}
+			delta := int(mag>>1 | 1<<zeros - 1)
+			if mag&1 == 1 {
+				delta = -delta
+			}
+			prev += delta
+			res = append(res, prev)
+		}
+	}
+}
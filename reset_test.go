@@ -0,0 +1,73 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestResetEncoder(t *testing.T) {
+	segments := [][]int{{}, {5, 9, 2}, {-1 << 63, 1<<63 - 1}, {0}}
+	bases := []int{3, 1000, -7, 1 << 62}
+	for _, n := range []int{500, 31} {
+		seg := make([]int, n)
+		v := rand.Int() - rand.Int()
+		for i := range seg {
+			v += rand.Intn(201) - 100
+			seg[i] = v
+		}
+		segments = append(segments, seg)
+		bases = append(bases, seg[0]+rand.Intn(11)-5)
+	}
+
+	buf := &bytes.Buffer{}
+	egs := NewResetEncoder(buf, bases[0])
+	want := make([]int, 0)
+	for i, seg := range segments {
+		if i > 0 {
+			egs.Reset(bases[i])
+		}
+		egs.Write(seg)
+		want = append(want, seg...)
+	}
+	egs.WriteInt(want[len(want)-1] + 1)
+	want = append(want, want[len(want)-1]+1)
+	if err := egs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := DecodeWithResets(bytes.NewReader(buf.Bytes()), bases[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(want) {
+		t.Fatalf("Got %d values, want %d", len(res), len(want))
+	}
+	for i := range want {
+		if res[i] != want[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], want[i])
+		}
+	}
+
+	// Without resets the stream is DeltaEncode's.
+	buf.Reset()
+	egs = NewResetEncoder(buf, 7)
+	egs.Write(segments[5])
+	egs.Close()
+	if !bytes.Equal(buf.Bytes(), DeltaEncode(7, segments[5])) {
+		t.Fatal("Stream without resets differs from DeltaEncode")
+	}
+
+	buf.Reset()
+	egs = NewResetEncoder(buf, 0)
+	egs.Write([]int{1, 2})
+	egs.Reset(50)
+	egs.Close()
+	if _, err := DecodeWithResets(bytes.NewReader(buf.Bytes()[:buf.Len()-2]), 0); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+	if _, err := DecodeWithResets(bytes.NewReader(make([]byte, 10)), 0); err != ErrResetMarker {
+		t.Fatal("Expected ErrResetMarker, got ", err)
+	}
+}
@@ -0,0 +1,101 @@
+package deltagolomb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// Remainders are pulled out of a single 64-bit load, which must hold
+// the field wherever in its first byte it starts.
+const maxRiceK = 56
+
+var (
+	ErrRiceParam = errors.New("deltagolomb: Rice parameter k out of range")
+	ErrRiceCount = errors.New("deltagolomb: implausible Rice value count")
+)
+
+// EncodeRicePlanar Rice codes values with parameter k, keeping the
+// quotients and remainders in separate planes:  the value count as an
+// unsigned varint, then each quotient v>>k in unary as that many 0
+// bits and a 1, then the k-bit remainders packed back to back.  With
+// every remainder the same width and at a known offset, decoding them
+// is a run of fixed shifts rather than a walk through the codewords.
+// k may be at most 56.
+func EncodeRicePlanar(w io.Writer, k uint, values []uint) error {
+	if k > maxRiceK {
+		return ErrRiceParam
+	}
+	var hdr [binary.MaxVarintLen64]byte
+	if _, err := w.Write(hdr[:binary.PutUvarint(hdr[:], uint64(len(values)))]); err != nil {
+		return err
+	}
+	egs := NewExpGolombEncoder(w)
+	for _, v := range values {
+		q := v >> k
+		for ; q >= 64; q -= 64 {
+			egs.WriteRawBits(0, 64)
+		}
+		egs.WriteRawBits(1, q+1)
+	}
+	for _, v := range values {
+		egs.WriteRawBits(uint64(v), k)
+	}
+	return egs.Close()
+}
+
+// DecodeRicePlanar reverses EncodeRicePlanar.  The caller must supply
+// the k used to encode.  Data that ends before every quotient and
+// remainder has been read gives io.ErrUnexpectedEOF, and a count that
+// overflows 64 bits gives ErrRiceCount.
+func DecodeRicePlanar(compressed []byte, k uint) ([]uint, error) {
+	if k > maxRiceK {
+		return nil, ErrRiceParam
+	}
+	count, n := binary.Uvarint(compressed)
+	if n <= 0 {
+		if n == 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, ErrRiceCount
+	}
+	nbits := 8 * (len(compressed) - n)
+	if count > uint64(nbits) {
+		// Every value takes at least its quotient's 1 bit.
+		return nil, io.ErrUnexpectedEOF
+	}
+	// Zero slack past the end lets every load take a full eight bytes.
+	data := make([]byte, len(compressed)-n+8)
+	copy(data, compressed[n:])
+
+	res := make([]uint, count)
+	pos := 0
+	for i := range res {
+		q := 0
+		for {
+			if pos >= nbits {
+				return nil, io.ErrUnexpectedEOF
+			}
+			shift := pos % 8
+			z := bits.LeadingZeros64(binary.BigEndian.Uint64(data[pos/8:]) << uint(shift))
+			if z < 64-shift {
+				q += z
+				pos += z + 1
+				break
+			}
+			q += 64 - shift
+			pos += 64 - shift
+		}
+		res[i] = uint(q) << k
+	}
+
+	if pos+len(res)*int(k) > nbits {
+		return nil, io.ErrUnexpectedEOF
+	}
+	for i := range res {
+		res[i] |= uint(binary.BigEndian.Uint64(data[pos/8:]) << uint(pos%8) >> (64 - k))
+		pos += int(k)
+	}
+	return res, nil
+}
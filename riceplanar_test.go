@@ -0,0 +1,117 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// encodeRiceInterleaved is the conventional layout, each remainder
+// straight after its quotient, as a reference for the benchmarks.
+func encodeRiceInterleaved(w io.Writer, k uint, values []uint) error {
+	egs := NewExpGolombEncoder(w)
+	for _, v := range values {
+		q := v >> k
+		for ; q >= 64; q -= 64 {
+			egs.WriteRawBits(0, 64)
+		}
+		egs.WriteRawBits(1, q+1)
+		egs.WriteRawBits(uint64(v), k)
+	}
+	return egs.Close()
+}
+
+func decodeRiceInterleaved(compressed []byte, k uint, count int) ([]uint, error) {
+	decoder := NewExpGolombDecoder(bytes.NewReader(compressed))
+	res := make([]uint, count)
+	for i := range res {
+		q := uint(0)
+		for {
+			b, err := decoder.ReadRawBits(1)
+			if err != nil {
+				return nil, err
+			}
+			if b == 1 {
+				break
+			}
+			q++
+		}
+		r, err := decoder.ReadRawBits(k)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = q<<k | uint(r)
+	}
+	return res, nil
+}
+
+func riceTestValues(n int, k uint) []uint {
+	values := make([]uint, n)
+	for i := range values {
+		values[i] = uint(rand.ExpFloat64() * float64(uint(1)<<k))
+	}
+	return values
+}
+
+func TestRicePlanar(t *testing.T) {
+	for _, k := range []uint{0, 1, 3, 8, 13, 56} {
+		values := append(riceTestValues(1000, k), 0, 1<<k-1, 1<<k, 200<<k+5)
+		buf := &bytes.Buffer{}
+		if err := EncodeRicePlanar(buf, k, values); err != nil {
+			t.Fatal(err)
+		}
+		compressed := buf.Bytes()
+		got, err := DecodeRicePlanar(compressed, k)
+		if err != nil {
+			t.Fatalf("k=%d: %v", k, err)
+		}
+		if len(got) != len(values) {
+			t.Fatalf("k=%d: got %d values, want %d", k, len(got), len(values))
+		}
+		for i := range got {
+			if got[i] != values[i] {
+				t.Fatalf("k=%d: item %d was %d, expected %d", k, i, got[i], values[i])
+			}
+		}
+
+		if _, err := DecodeRicePlanar(compressed[:len(compressed)-2], k); err != io.ErrUnexpectedEOF {
+			t.Fatalf("k=%d: expected io.ErrUnexpectedEOF, got %v", k, err)
+		}
+	}
+
+	if got, err := DecodeRicePlanar([]byte{0}, 4); err != nil || len(got) != 0 {
+		t.Fatal("Empty stream gave ", got, err)
+	}
+	if err := EncodeRicePlanar(&bytes.Buffer{}, 57, nil); err != ErrRiceParam {
+		t.Fatal("Expected ErrRiceParam, got ", err)
+	}
+	count := bytes.Repeat([]byte{0xff}, 10)
+	if _, err := DecodeRicePlanar(append(count, 1), 4); err != ErrRiceCount {
+		t.Fatal("Expected ErrRiceCount, got ", err)
+	}
+}
+
+func BenchmarkRicePlanarDecode(b *testing.B) {
+	values := riceTestValues(10000, 6)
+	buf := &bytes.Buffer{}
+	EncodeRicePlanar(buf, 6, values)
+	compressed := buf.Bytes()
+	b.SetBytes(int64(len(compressed)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DecodeRicePlanar(compressed, 6)
+	}
+}
+
+func BenchmarkRiceInterleavedDecode(b *testing.B) {
+	values := riceTestValues(10000, 6)
+	buf := &bytes.Buffer{}
+	encodeRiceInterleaved(buf, 6, values)
+	compressed := buf.Bytes()
+	b.SetBytes(int64(len(compressed)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decodeRiceInterleaved(compressed, 6, len(values))
+	}
+}
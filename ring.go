@@ -0,0 +1,48 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrWindow = errors.New("deltagolomb: window must be positive")
+
+// DecodeRing decodes a delta-coded stream from r without holding more
+// than window values, calling fn after each value with the most
+// recent ones, oldest first.  Until window values have been decoded
+// fn sees all of them.  The slice is only valid during the call; fn
+// must copy anything it keeps.  Returns nil at the end of the stream,
+// or io.ErrUnexpectedEOF if it ends inside a codeword.
+func DecodeRing(base int, r io.Reader, window int, fn func(window []int)) error {
+	if window <= 0 {
+		return ErrWindow
+	}
+	// Each value is stored twice, window apart, so the last window
+	// values always sit contiguously in buf.
+	buf := make([]int, 2*window)
+	decoder := NewExpGolombDecoder(r)
+	tmp := make([]int, 256)
+	val, count := base, 0
+	for {
+		n, err := decoder.Read(tmp)
+		for _, d := range tmp[:n] {
+			val += d
+			i := count % window
+			buf[i], buf[i+window] = val, val
+			count++
+			if count < window {
+				fn(buf[:count])
+			} else {
+				fn(buf[i+1 : i+1+window])
+			}
+		}
+		if err == io.EOF && decoder.truncated() {
+			return io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestDecodeRing(t *testing.T) {
+	data := make([]int, 1000)
+	v := 0
+	for i := range data {
+		v += rand.Intn(201) - 100
+		data[i] = v
+	}
+	compressed := DeltaEncode(4, data)
+
+	for _, window := range []int{1, 2, 7, 64, 2000} {
+		pos := 0
+		err := DecodeRing(4, bytes.NewReader(compressed), window, func(w []int) {
+			// Brute force:  the last window values ending at pos.
+			lo := pos + 1 - window
+			if lo < 0 {
+				lo = 0
+			}
+			want := data[lo : pos+1]
+			if len(w) != len(want) {
+				t.Fatalf("Window %d at %d: got %d values, want %d", window, pos, len(w), len(want))
+			}
+			sum, wantSum := 0, 0
+			for i := range want {
+				if w[i] != want[i] {
+					t.Fatalf("Window %d at %d: got %v, want %v", window, pos, w, want)
+				}
+				sum += w[i]
+				wantSum += want[i]
+			}
+			if sum != wantSum {
+				t.Fatalf("Window %d at %d: moving sum %d, want %d", window, pos, sum, wantSum)
+			}
+			pos++
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos != len(data) {
+			t.Fatalf("Window %d: fn called %d times, want %d", window, pos, len(data))
+		}
+	}
+
+	if err := DecodeRing(0, bytes.NewReader(nil), 0, func([]int) {}); err != ErrWindow {
+		t.Fatal("Expected ErrWindow, got ", err)
+	}
+	if err := DecodeRing(0, bytes.NewReader([]byte{0, 0}), 3, func([]int) {}); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+}
@@ -0,0 +1,63 @@
+package deltagolomb
+
+import (
+	"math/bits"
+	"strconv"
+)
+
+// RoundTripError reports where re-encoding a decoded stream stopped
+// matching the original.
+type RoundTripError struct {
+	Offset int // first bit that differs
+	Index  int // value whose codeword holds that bit; the value count if past them all
+	Value  int // absolute value at Index, if Index is a value
+	Values int // number of values decoded
+}
+
+func (e *RoundTripError) Error() string {
+	msg := "deltagolomb: re-encoded stream differs at bit " + strconv.Itoa(e.Offset)
+	if e.Index >= e.Values {
+		return msg + ", after the last of " + strconv.Itoa(e.Values) + " values"
+	}
+	return msg + ", in value " + strconv.Itoa(e.Index) + " (" + strconv.Itoa(e.Value) + ")"
+}
+
+// RoundTripCheck decodes compressed as DeltaDecode does, re-encodes
+// the result and checks that the bytes match, including the zero
+// padding that Close writes.  Anything the decoder silently drops,
+// such as a truncated final codeword, nonzero padding or extra zero
+// bytes, shows up as a mismatch, as does a value too large for the
+// decoder to rebuild exactly.  Returns nil or a *RoundTripError.
+func RoundTripCheck(base int, compressed []byte) error {
+	residuals := DecodeResiduals(compressed)
+	again := DeltaEncode(0, Integrate(0, residuals))
+
+	offset := -1
+	for i := 0; i < len(compressed) && i < len(again); i++ {
+		if d := compressed[i] ^ again[i]; d != 0 {
+			offset = 8*i + bits.LeadingZeros8(d)
+			break
+		}
+	}
+	if offset < 0 {
+		if len(compressed) == len(again) {
+			return nil
+		}
+		offset = 8 * len(again)
+		if len(compressed) < len(again) {
+			offset = 8 * len(compressed)
+		}
+	}
+
+	e := &RoundTripError{Offset: offset, Index: len(residuals), Values: len(residuals)}
+	pos, val := 0, base
+	for i, r := range residuals {
+		val += r
+		pos += codeLen(r)
+		if offset < pos {
+			e.Index, e.Value = i, val
+			break
+		}
+	}
+	return e
+}
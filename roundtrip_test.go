@@ -0,0 +1,46 @@
+package deltagolomb
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRoundTripCheck(t *testing.T) {
+	data := make([]int, 500)
+	v := 0
+	for i := range data {
+		v += rand.Intn(201) - 100
+		data[i] = v
+	}
+	compressed := DeltaEncode(3, data)
+	if err := RoundTripCheck(3, compressed); err != nil {
+		t.Fatal("Valid stream failed: ", err)
+	}
+	if err := RoundTripCheck(0, nil); err != nil {
+		t.Fatal("Empty stream failed: ", err)
+	}
+
+	// 3, 4, 2 codes as residuals 3, 1, -2:  00100 0, 010 0, 011 1, pad.
+	stream := []byte{0x21, 0x1c}
+	if err := RoundTripCheck(0, stream); err != nil {
+		t.Fatal("Small stream failed: ", err)
+	}
+	// Flipping a padding bit is dropped by the decoder.
+	err := RoundTripCheck(0, []byte{0x21, 0x1d})
+	rt, ok := err.(*RoundTripError)
+	if !ok || rt.Offset != 15 || rt.Index != 3 || rt.Values != 3 {
+		t.Fatalf("Mangled padding gave %#v", err)
+	}
+	// A trailing zero byte decodes to nothing.
+	err = RoundTripCheck(0, []byte{0x21, 0x1c, 0})
+	if rt, ok := err.(*RoundTripError); !ok || rt.Offset != 16 || rt.Index != 3 {
+		t.Fatalf("Extra zero byte gave %#v", err)
+	}
+	// A codeword cut off by the end of the stream is dropped.
+	err = RoundTripCheck(0, []byte{0x20, 0x00, 0x01})
+	if rt, ok := err.(*RoundTripError); !ok || rt.Offset != 8 || rt.Index != 1 || rt.Values != 1 {
+		t.Fatalf("Truncated codeword gave %#v", err)
+	} else if err.Error() != "deltagolomb: re-encoded stream differs at bit 8, after the last of 1 values" {
+		t.Fatal("Unexpected message: ", err)
+	}
+}
@@ -0,0 +1,67 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrBlockLength = errors.New("deltagolomb: implausible block length")
+
+// WriteBlockSelfBased writes values as a block that carries its own
+// base, so that blocks appended to one stream can each be decoded, or
+// dropped, without the others.  The block is values[0] as a raw
+// 64-bit field, the number of values as an unsigned codeword, and the
+// deltas of the remaining values, padded to a whole byte.
+func WriteBlockSelfBased(w io.Writer, values []int) error {
+	base := 0
+	if len(values) > 0 {
+		base = values[0]
+	}
+	egs := NewExpGolombEncoder(w)
+	egs.WriteRawBits(uint64(base), 64)
+	egs.WriteUnsigned(uint(len(values)))
+	for i := 1; i < len(values); i++ {
+		egs.WriteInt(values[i] - values[i-1])
+	}
+	return egs.Close()
+}
+
+// DecodeBlockSelfBased reads one block written by WriteBlockSelfBased.
+// It stops at the end of the block, so consecutive blocks can be read
+// from one stream by calling it repeatedly, provided r is an
+// io.ByteReader such as a *bytes.Reader or *bufio.Reader; other
+// readers are buffered and may be read past the block.  At the end of
+// the stream it returns io.EOF; a block cut short gives
+// io.ErrUnexpectedEOF, and one claiming more than 2^31 values gives
+// ErrBlockLength.
+func DecodeBlockSelfBased(r io.Reader) ([]int, error) {
+	decoder := NewExpGolombDecoder(r)
+	raw, err := decoder.ReadRawBits(64)
+	if err != nil {
+		return nil, err
+	}
+	count := make([]uint, 1)
+	if n, err := decoder.ReadUnsigned(count); n == 0 {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if count[0] > 1<<31 {
+		return nil, ErrBlockLength
+	}
+	if count[0] == 0 {
+		return make([]int, 0), nil
+	}
+
+	deltas, err := readN(count[0]-1, decoder.Read)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]int, count[0])
+	values[0] = int(raw)
+	for i, d := range deltas {
+		values[i+1] = values[i] + d
+	}
+	return values, nil
+}
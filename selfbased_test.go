@@ -0,0 +1,103 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestBlockSelfBased(t *testing.T) {
+	blocks := [][]int{{}, {-1 << 63}, {5, 9, 2}}
+	for _, n := range []int{1000, 37} {
+		block := make([]int, n)
+		v := rand.Int() - rand.Int()
+		for i := range block {
+			v += rand.Intn(2001) - 1000
+			block[i] = v
+		}
+		blocks = append(blocks, block)
+	}
+
+	buf := &bytes.Buffer{}
+	offsets := make([]int, 0)
+	for _, block := range blocks {
+		offsets = append(offsets, buf.Len())
+		if err := WriteBlockSelfBased(buf, block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream := buf.Bytes()
+
+	check := func(i int, got []int) {
+		if len(got) != len(blocks[i]) {
+			t.Fatalf("Block %d: got %d values, want %d", i, len(got), len(blocks[i]))
+		}
+		for j := range got {
+			if got[j] != blocks[i][j] {
+				t.Fatalf("Block %d item %d was %d, expected %d", i, j, got[j], blocks[i][j])
+			}
+		}
+	}
+
+	// In sequence from one reader.
+	r := bytes.NewReader(stream)
+	for i := range blocks {
+		got, err := DecodeBlockSelfBased(r)
+		if err != nil {
+			t.Fatalf("Block %d: %v", i, err)
+		}
+		check(i, got)
+	}
+	if _, err := DecodeBlockSelfBased(r); err != io.EOF {
+		t.Fatal("Expected io.EOF after the last block, got ", err)
+	}
+
+	// Each on its own, as if the blocks before it were dropped.
+	for i := range blocks {
+		got, err := DecodeBlockSelfBased(bytes.NewReader(stream[offsets[i]:]))
+		if err != nil {
+			t.Fatalf("Block %d alone: %v", i, err)
+		}
+		check(i, got)
+	}
+
+	if _, err := DecodeBlockSelfBased(bytes.NewReader(stream[offsets[3] : offsets[3]+20])); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+}
+
+func TestBlockSelfBasedEmpty(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteBlockSelfBased(buf, []int{}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() == 0 {
+		t.Fatal("Empty block wrote no bytes")
+	}
+	got, err := DecodeBlockSelfBased(buf)
+	if err != nil || got == nil || len(got) != 0 {
+		t.Fatalf("Empty block decoded as %#v, %v", got, err)
+	}
+	if _, err := DecodeBlockSelfBased(buf); err != io.EOF {
+		t.Fatal("Expected io.EOF for a missing block, got ", err)
+	}
+}
+
+func TestBlockSelfBasedBadLength(t *testing.T) {
+	for _, count := range []uint{1 << 31, 1<<31 + 1} {
+		buf := &bytes.Buffer{}
+		e := NewExpGolombEncoder(buf)
+		e.WriteRawBits(7, 64)
+		e.WriteUnsigned(count)
+		e.Write([]int{1, -1})
+		e.Close()
+		want := io.ErrUnexpectedEOF
+		if count > 1<<31 {
+			want = ErrBlockLength
+		}
+		if _, err := DecodeBlockSelfBased(buf); err != want {
+			t.Fatalf("Count %d: got %v, want %v", count, err, want)
+		}
+	}
+}
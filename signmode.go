@@ -0,0 +1,70 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// SignMode selects how signed values are mapped onto codewords.
+type SignMode byte
+
+const (
+	// SignBit codes the magnitude and follows every nonzero value
+	// with an explicit sign bit.  This is the default.
+	SignBit SignMode = iota
+	// ZigZag interleaves positive and negative values (0, -1, 1,
+	// -2, 2, ...) onto the unsigned code, with no separate sign bit.
+	ZigZag
+	// Positive codes values of one or more, such as 1-based gaps,
+	// as the unsigned code of value-1, so the shortest codeword
+	// stands for 1.  Values below 1 are skipped, and Close reports
+	// ErrPositiveMode.  Read gives ErrOverflow for a codeword whose
+	// value plus one does not fit in an int.
+	Positive
+)
+
+var (
+	ErrSignMode     = errors.New("deltagolomb: unknown sign mode in header")
+	ErrPositiveMode = errors.New("deltagolomb: Positive mode cannot encode values below 1")
+)
+
+// Create a new Exp-Golomb stream Encoder that codes signed values
+// using mode.  The mode is recorded in a one-byte header so that
+// NewExpGolombDecoderAuto can pick it up.
+func NewExpGolombEncoderMode(w io.Writer, mode SignMode) *ExpGolombEncoder {
+	s := NewExpGolombEncoder(w)
+	s.addBits(uint(mode), 8)
+	s.mode = mode
+	return s
+}
+
+// Create a new Exp-Golomb stream decoder for a stream written by
+// NewExpGolombEncoderMode.  Reads the header byte from r and decodes
+// the rest of the stream in the mode it names.
+func NewExpGolombDecoderAuto(r io.Reader) (*ExpGolombDecoder, error) {
+	d := NewExpGolombDecoder(r)
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	d.consumed++
+	mode := SignMode(b)
+	if !mode.valid() {
+		return nil, ErrSignMode
+	}
+	d.mode = mode
+	return d, nil
+}
+
+func (m SignMode) valid() bool {
+	return m <= Positive
+}
+
+func zigZag(i int) uint {
+	return uint((i << 1) ^ (i >> (bits.UintSize - 1)))
+}
+
+func unZigZag(u uint) int {
+	return int(u>>1) ^ -int(u&1)
+}
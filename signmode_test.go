@@ -0,0 +1,114 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestZigZag(t *testing.T) {
+	for i, want := range []uint{0, 1, 2, 3, 4} {
+		v := []int{0, -1, 1, -2, 2}[i]
+		if zigZag(v) != want {
+			t.Fatalf("zigZag(%d) = %d, want %d", v, zigZag(v), want)
+		}
+	}
+	for _, v := range []int{0, 1, -1, 1<<62 - 1, -(1 << 62), 1<<63 - 1, -1 << 63} {
+		if unZigZag(zigZag(v)) != v {
+			t.Fatalf("unZigZag(zigZag(%d)) = %d", v, unZigZag(zigZag(v)))
+		}
+	}
+}
+
+func TestSignModes(t *testing.T) {
+	vals := make([]int, 0)
+	for i := -5000; i <= 5000; i++ {
+		vals = append(vals, i*7)
+	}
+	for _, mode := range []SignMode{SignBit, ZigZag} {
+		buf := &bytes.Buffer{}
+		encoder := NewExpGolombEncoderMode(buf, mode)
+		encoder.Write(vals)
+		encoder.Close()
+		if buf.Bytes()[0] != byte(mode) {
+			t.Fatalf("Mode %d: header byte was %d", mode, buf.Bytes()[0])
+		}
+
+		decoder, err := NewExpGolombDecoderAuto(buf)
+		if err != nil {
+			t.Fatalf("Mode %d: %v", mode, err)
+		}
+		res := make([]int, len(vals))
+		n, _ := decoder.Read(res)
+		if n != len(vals) {
+			t.Fatalf("Mode %d: got %d values, want %d", mode, n, len(vals))
+		}
+		for i := range vals {
+			if res[i] != vals[i] {
+				t.Fatalf("Mode %d: item %d was %d, expected %d", mode, i, res[i], vals[i])
+			}
+		}
+	}
+
+	if _, err := NewExpGolombDecoderAuto(bytes.NewBuffer([]byte{7})); err != ErrSignMode {
+		t.Fatal("Expected ErrSignMode for a bad header, got ", err)
+	}
+}
+
+func TestPositiveMode(t *testing.T) {
+	// Header, then 1 as the one-bit codeword and 2 as 010.
+	buf := &bytes.Buffer{}
+	encoder := NewExpGolombEncoderMode(buf, Positive)
+	encoder.Write([]int{1, 2})
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), []byte{byte(Positive), 0xa0}) {
+		t.Fatalf("Encoded as %x", buf.Bytes())
+	}
+
+	gaps := []int{1, 1, 3, 1, 1000, 2, 1, 1 << 40}
+	buf.Reset()
+	encoder = NewExpGolombEncoderMode(buf, Positive)
+	encoder.Write(gaps)
+	encoder.Close()
+	decoder, err := NewExpGolombDecoderAuto(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := make([]int, len(gaps)+1)
+	if n, _ := decoder.Read(res); n != len(gaps) {
+		t.Fatalf("Got %d values, want %d", n, len(gaps))
+	}
+	for i := range gaps {
+		if res[i] != gaps[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], gaps[i])
+		}
+	}
+
+	encoder = NewExpGolombEncoderMode(&bytes.Buffer{}, Positive)
+	encoder.Write([]int{3, 0, 4})
+	if encoder.ValuesWritten() != 2 {
+		t.Fatalf("Counted %d values, want 2", encoder.ValuesWritten())
+	}
+	if err := encoder.Close(); err != ErrPositiveMode {
+		t.Fatal("Expected ErrPositiveMode, got ", err)
+	}
+
+	// Codewords for MaxInt-1 and MaxInt:  the second has no int
+	// one larger.
+	buf.Reset()
+	encoder = NewExpGolombEncoderMode(buf, Positive)
+	encoder.WriteUnsigned(math.MaxInt - 1)
+	encoder.WriteUnsigned(math.MaxInt)
+	encoder.WriteUnsigned(^uint(0))
+	encoder.Close()
+	decoder, _ = NewExpGolombDecoderAuto(buf)
+	res = []int{0, -7, -7}
+	if n, err := decoder.Read(res); n != 1 || err != ErrOverflow || res[0] != math.MaxInt || res[1] != -7 {
+		t.Fatalf("Read gave %d, %v, %v", n, err, res)
+	}
+	if n, err := decoder.Read(res); n != 0 || err != ErrOverflow {
+		t.Fatalf("Read of 2^64-1 gave %d, %v", n, err)
+	}
+}
@@ -0,0 +1,105 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+)
+
+var ErrEncoderState = errors.New("deltagolomb: invalid encoder state")
+
+// EncoderState is the part of an encoder's bit state that has not yet
+// reached its writer, as captured by Snapshot.
+type EncoderState struct {
+	Bits  byte     // pending bits, left-aligned; the rest are zero
+	NBits uint     // number of pending bits, 0 to 7
+	Mode  SignMode // sign mode in use
+}
+
+// Snapshot flushes every complete byte to the underlying writer and
+// returns the few bits left over.  The bytes flushed so far and the
+// returned state together are enough to carry on encoding later with
+// NewExpGolombEncoderFrom; persisting the bytes is up to the caller.
+// Encoding may continue after Snapshot.
+func (s *ExpGolombEncoder) Snapshot() (EncoderState, error) {
+	_, err := s.Flush()
+	return EncoderState{
+		Bits:  byte(s.data >> (egWordBits - 8)),
+		NBits: egWordBits - s.bitsleft,
+		Mode:  s.mode,
+	}, err
+}
+
+// Create an Exp-Golomb stream Encoder that resumes from state, writing
+// to w.  Appending its output to the bytes flushed before the
+// Snapshot gives the same stream a single encoder would have written.
+// No mode header is written; the state's mode is used as is.
+func NewExpGolombEncoderFrom(w io.Writer, state EncoderState) (*ExpGolombEncoder, error) {
+	if state.NBits > 7 || state.Bits&(0xff>>state.NBits) != 0 || !state.Mode.valid() {
+		return nil, ErrEncoderState
+	}
+	s := NewExpGolombEncoder(w)
+	s.mode = state.Mode
+	s.addBits(uint(state.Bits>>(8-state.NBits)), state.NBits)
+	return s, nil
+}
+
+var ErrRollback = errors.New("deltagolomb: no checkpoint to roll back to, or bytes already written past it")
+
+// checkpoint is the encoder state saved by BeginValue.
+type checkpoint struct {
+	set      bool
+	data     uint64
+	bitsleft uint
+	values   int
+	written  int
+	flushed  int
+}
+
+// BeginValue records the encoder's state before the next value, so
+// that the value can be taken back with Rollback if writing it fails.
+// If the writer fails while WriteInt or WriteUnsigned encodes that
+// value, the encoder returns to the checkpoint by itself; the error
+// stays until Rollback clears it.
+//
+// Only state still held by the encoder can be restored.  Bytes that
+// reach the writer cannot be taken back, so once the writer has
+// accepted any byte since BeginValue, Rollback fails with
+// ErrRollback.  A writer that is not an io.ByteWriter is wrapped in a
+// bufio.Writer, which accepts bytes before they fail; rollback is
+// only useful with a writer that reports a failed write having taken
+// nothing.
+func (s *ExpGolombEncoder) BeginValue() {
+	s.cp = checkpoint{
+		set:      true,
+		data:     s.data,
+		bitsleft: s.bitsleft,
+		values:   s.values,
+		written:  s.written,
+		flushed:  s.flushed,
+	}
+}
+
+// Rollback returns the encoder to the state saved by the last
+// BeginValue and clears any write error, so that the value can be
+// written again once the writer has recovered.  The checkpoint is
+// kept, for further attempts.
+func (s *ExpGolombEncoder) Rollback() error {
+	if !s.cp.set || s.flushed != s.cp.flushed {
+		return ErrRollback
+	}
+	s.restore()
+	s.err = nil
+	return nil
+}
+
+// restore puts back the checkpoint's bit state, unless bytes have
+// been written since it was taken.
+func (s *ExpGolombEncoder) restore() {
+	if s.flushed != s.cp.flushed {
+		return
+	}
+	s.data = s.cp.data
+	s.bitsleft = s.cp.bitsleft
+	s.values = s.cp.values
+	s.written = s.cp.written
+}
@@ -0,0 +1,115 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	vals := make([]int, 1001)
+	for i := range vals {
+		vals[i] = rand.Intn(2001) - 1000
+	}
+
+	for _, mode := range []SignMode{SignBit, ZigZag} {
+		whole := &bytes.Buffer{}
+		egs := NewExpGolombEncoderMode(whole, mode)
+		egs.Write(vals)
+		egs.Close()
+
+		for _, split := range []int{0, 1, 2, 500, 1001} {
+			buf := &bytes.Buffer{}
+			egs := NewExpGolombEncoderMode(buf, mode)
+			egs.Write(vals[:split])
+			state, err := egs.Snapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The first encoder is abandoned, as after a crash.
+			resumed, err := NewExpGolombEncoderFrom(buf, state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resumed.Write(vals[split:])
+			resumed.Close()
+			if !bytes.Equal(buf.Bytes(), whole.Bytes()) {
+				t.Fatalf("Mode %d, split %d: resumed stream differs", mode, split)
+			}
+		}
+	}
+
+	for _, bad := range []EncoderState{{NBits: 8}, {Bits: 0x10, NBits: 3}, {Mode: 7}} {
+		if _, err := NewExpGolombEncoderFrom(&bytes.Buffer{}, bad); err != ErrEncoderState {
+			t.Fatalf("State %+v: expected ErrEncoderState, got %v", bad, err)
+		}
+	}
+}
+
+// flakyWriter accepts single bytes and fails every write while down.
+type flakyWriter struct {
+	bytes.Buffer
+	down bool
+}
+
+func (f *flakyWriter) Write(p []byte) (int, error) {
+	if f.down {
+		return 0, errFailWriter
+	}
+	return f.Buffer.Write(p)
+}
+
+func (f *flakyWriter) Flush() error { return nil }
+
+func TestRollback(t *testing.T) {
+	vals := []int{3, -7, 1 << 40, 0, 12345, -1, 1 << 50, 99}
+	fw := &flakyWriter{}
+	egs := NewExpGolombEncoder(fw)
+	failures := 0
+	for i, v := range vals {
+		// The connection drops at every third value.
+		fw.down = i%3 == 2
+		for {
+			egs.BeginValue()
+			egs.WriteInt(v)
+			if _, err := egs.WriteAll(nil); err == nil {
+				break
+			}
+			failures++
+			fw.down = false
+			if err := egs.Rollback(); err != nil {
+				t.Fatal("Rollback failed: ", err)
+			}
+		}
+	}
+	if err := egs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if failures == 0 {
+		t.Fatal("The writer never failed")
+	}
+	if egs.ValuesWritten() != len(vals) {
+		t.Fatalf("Wrote %d values, want %d", egs.ValuesWritten(), len(vals))
+	}
+	res := make([]int, len(vals)+1)
+	if n, _ := NewExpGolombDecoder(&fw.Buffer).Read(res); n != len(vals) {
+		t.Fatalf("Decoded %d values, want %d", n, len(vals))
+	}
+	for i := range vals {
+		if res[i] != vals[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], vals[i])
+		}
+	}
+
+	// Bytes that reached the writer can't be taken back.
+	egs = NewExpGolombEncoder(&bytes.Buffer{})
+	if err := egs.Rollback(); err != ErrRollback {
+		t.Fatal("Expected ErrRollback without a checkpoint, got ", err)
+	}
+	egs.BeginValue()
+	egs.WriteInt(1 << 50)
+	egs.WriteInt(1 << 50)
+	if err := egs.Rollback(); err != ErrRollback {
+		t.Fatal("Expected ErrRollback after bytes were written, got ", err)
+	}
+}
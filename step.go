@@ -0,0 +1,71 @@
+package deltagolomb
+
+import (
+	"io"
+	"math"
+)
+
+// DecodeState is the position of DecodeStep within a codeword.  The
+// zero value is the state between codewords, where decoding starts.
+type DecodeState struct {
+	phase int // COUNTING_ZEROS, SHIFTING_BITS or READING_SIGN
+	zeros int
+	val   int
+	ended bool // terminator seen
+}
+
+// Between reports whether s lies between codewords, so that the
+// bits fed so far end cleanly.  Trailing zeros that never reach a
+// one leave s inside a codeword; at the end of a stream fewer than
+// eight of them are padding.
+func (s DecodeState) Between() bool {
+	return s.phase == COUNTING_ZEROS && s.zeros == 0
+}
+
+// DecodeStep feeds one bit, 0 or 1, of a sign-bit Exp-Golomb stream
+// to the same state machine ExpGolombDecoder.Read runs, for callers
+// that take bits from a source of their own.  It returns the next
+// state, and when the bit completes a codeword, the value.  As with
+// Read, a value too large for an int gives ErrOverflow, and the
+// terminator written by UseTerminator gives io.EOF, as does every bit
+// fed after it.  On an error the state is returned unchanged.
+func DecodeStep(state DecodeState, bit uint) (next DecodeState, emitted bool, value int, err error) {
+	if state.ended {
+		return state, false, 0, io.EOF
+	}
+	switch state.phase {
+	case COUNTING_ZEROS:
+		if bit == 0 {
+			state.zeros++
+		} else if state.zeros == 0 {
+			return state, true, 0, nil
+		} else if state.zeros == terminatorZeros {
+			return DecodeState{ended: true}, false, 0, io.EOF
+		} else if state.zeros >= 64 {
+			// The magnitude would not fit in 64 bits.
+			return state, false, 0, ErrOverflow
+		} else {
+			state.phase = SHIFTING_BITS
+			state.val = 1
+		}
+	case SHIFTING_BITS:
+		state.val = state.val<<1 | int(bit&1)
+		state.zeros--
+		if state.zeros == 0 {
+			state.val -= 1 // Because we stole bit for 0.
+			state.phase = READING_SIGN
+		}
+	case READING_SIGN:
+		// A magnitude of 2^63 or more has wrapped negative;
+		// only -2^63 itself fits.
+		if state.val < 0 && (bit == 0 || state.val != math.MinInt) {
+			return state, false, 0, ErrOverflow
+		}
+		value = state.val
+		if bit == 1 {
+			value = -value
+		}
+		return DecodeState{}, true, value, nil
+	}
+	return state, false, 0, nil
+}
@@ -0,0 +1,122 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDecodeStep(t *testing.T) {
+	vals := []int{0, 1, -1, 2, -2, 3, -8, 1000, -1 << 40, 1<<62 + 5}
+	for i := 0; i < 1000; i++ {
+		vals = append(vals, rand.Intn(20001)-10000)
+	}
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	egs.Write(vals)
+	egs.Close()
+	stream := buf.Bytes()
+
+	want := make([]int, len(vals)+1)
+	n, _ := NewExpGolombDecoder(bytes.NewReader(stream)).Read(want)
+	want = want[:n]
+
+	res := make([]int, 0)
+	var state DecodeState
+	for _, b := range stream {
+		for i := 7; i >= 0; i-- {
+			var emitted bool
+			var v int
+			var err error
+			state, emitted, v, err = DecodeStep(state, uint(b>>uint(i))&1)
+			if err != nil {
+				t.Fatal("DecodeStep failed: ", err)
+			}
+			if emitted {
+				res = append(res, v)
+			}
+		}
+	}
+	if len(res) != len(want) {
+		t.Fatalf("Got %d values, Read gave %d", len(res), len(want))
+	}
+	for i := range want {
+		if res[i] != want[i] || res[i] != vals[i] {
+			t.Fatalf("Item %d was %d, Read gave %d, expected %d", i, res[i], want[i], vals[i])
+		}
+	}
+	// Only padding zeros are left over.
+	if state.phase != COUNTING_ZEROS || state.zeros >= 8 {
+		t.Fatalf("Stream ended in state %+v", state)
+	}
+
+	// 0100 is +1; the state is mid-codeword until the sign bit.
+	state = DecodeState{}
+	for i, bit := range []uint{0, 1, 0} {
+		if state, _, _, _ = DecodeStep(state, bit); state.Between() {
+			t.Fatalf("Between after bit %d", i)
+		}
+	}
+	if state, emitted, v, err := DecodeStep(state, 0); !emitted || v != 1 || !state.Between() || err != nil {
+		t.Fatalf("Sign bit gave %+v, %v, %d, %v", state, emitted, v, err)
+	}
+}
+
+// DecodeStep must fail where Read does.
+func TestDecodeStepErrors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	egs := NewExpGolombEncoder(buf)
+	egs.UseTerminator(true)
+	egs.Write([]int{5, math.MinInt})
+	egs.Close()
+	terminated := buf.Bytes()
+
+	cases := []struct {
+		name   string
+		stream []byte
+		want   error
+	}{
+		// A 70-zero prefix, then a one.
+		{"long prefix", []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x02}, ErrOverflow},
+		// 64 zeros:  too long for a signed value.
+		{"64 zeros", []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x80}, ErrOverflow},
+		// 63 zeros, a magnitude of 2^63, positive.
+		{"2^63", []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0x02}, ErrOverflow},
+		{"terminator", terminated, io.EOF},
+	}
+	for _, c := range cases {
+		want := make([]int, 3)
+		n, rerr := NewExpGolombDecoder(bytes.NewReader(c.stream)).Read(want)
+		if rerr != c.want {
+			t.Fatalf("%s: Read gave %v, want %v", c.name, rerr, c.want)
+		}
+
+		var state DecodeState
+		var err error
+		res := make([]int, 0)
+		for pos := 0; pos < 8*len(c.stream) && err == nil; pos++ {
+			var emitted bool
+			var v int
+			state, emitted, v, err = DecodeStep(state, uint(c.stream[pos/8]>>uint(7-pos%8))&1)
+			if emitted {
+				res = append(res, v)
+			}
+		}
+		if err != c.want || len(res) != n {
+			t.Fatalf("%s: DecodeStep gave %v, %v; Read gave %v, %v", c.name, res, err, want[:n], rerr)
+		}
+		if _, _, _, err = DecodeStep(state, 1); c.want == io.EOF && err != io.EOF {
+			t.Fatalf("%s: step after the terminator gave %v", c.name, err)
+		}
+
+		values, _, err := DecodeWithLengths(c.stream)
+		if c.want == io.EOF && (err != nil || len(values) != n) {
+			t.Fatalf("%s: DecodeWithLengths gave %v, %v", c.name, values, err)
+		}
+		if c.want != io.EOF && err != c.want {
+			t.Fatalf("%s: DecodeWithLengths gave %v, want %v", c.name, err, c.want)
+		}
+	}
+}
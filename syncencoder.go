@@ -0,0 +1,51 @@
+package deltagolomb
+
+import (
+	"io"
+	"sync"
+)
+
+// SyncEncoder wraps an ExpGolombEncoder so that several goroutines
+// can append to one stream.  ExpGolombEncoder itself is not safe for
+// concurrent use:  its bit accumulator is shared state, and racing
+// writers corrupt the stream.  Each call holds the lock for its whole
+// duration, so the values of a single Write stay contiguous.
+type SyncEncoder struct {
+	mu  sync.Mutex
+	enc *ExpGolombEncoder
+}
+
+// Create a new SyncEncoder writing to w, as NewExpGolombEncoder does.
+func NewSyncEncoder(w io.Writer) *SyncEncoder {
+	return &SyncEncoder{enc: NewExpGolombEncoder(w)}
+}
+
+// Encode a slice of signed integers without interleaving them with
+// values from other goroutines.
+func (s *SyncEncoder) Write(ilist []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Write(ilist)
+}
+
+// Encode a single signed integer into the byte stream.
+func (s *SyncEncoder) WriteInt(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.WriteInt(i)
+}
+
+// Flush pushes complete bytes through, as ExpGolombEncoder.Flush does.
+func (s *SyncEncoder) Flush() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Flush()
+}
+
+// Close pads and flushes the stream.  No goroutine may write after
+// Close.
+func (s *SyncEncoder) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Close()
+}
@@ -0,0 +1,51 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestSyncEncoder(t *testing.T) {
+	const writers = 16
+	const perWriter = 1000
+	buf := &bytes.Buffer{}
+	enc := NewSyncEncoder(buf)
+
+	// Writer g emits g*perWriter+i in order, alternating WriteInt
+	// with pairs passed to Write, negated to exercise the sign bit.
+	var wg sync.WaitGroup
+	for g := 0; g < writers; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i += 3 {
+				enc.WriteInt(-(g*perWriter + i))
+				if i+2 < perWriter {
+					enc.Write([]int{-(g*perWriter + i + 1), -(g*perWriter + i + 2)})
+				} else if i+1 < perWriter {
+					enc.WriteInt(-(g*perWriter + i + 1))
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	res := make([]int, writers*perWriter+1)
+	n, _ := NewExpGolombDecoder(buf).Read(res)
+	if n != writers*perWriter {
+		t.Fatalf("Decoded %d values, expected %d", n, writers*perWriter)
+	}
+	next := make([]int, writers)
+	for i, v := range res[:n] {
+		v = -v
+		g := v / perWriter
+		if g < 0 || g >= writers || v%perWriter != next[g] {
+			t.Fatalf("Item %d was %d, not a valid interleaving", i, -v)
+		}
+		next[g]++
+	}
+}
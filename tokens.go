@@ -0,0 +1,177 @@
+package deltagolomb
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+)
+
+var ErrToken = errors.New("deltagolomb: malformed token stream")
+
+// Token types, written as two raw bits.
+const (
+	tokenCodes   = 0 // count sign-bit codewords
+	tokenZeros   = 1 // count zeros, no payload
+	tokenLiteral = 2 // a 6-bit width less one, then count raw values
+)
+
+// Values are tokenized a window at a time.
+const tokenWindow = 32
+
+type token struct {
+	kind, width int
+	values      []int
+}
+
+// literalWidth returns the bits needed to hold every value in vals as
+// two's complement.
+func literalWidth(vals []int) int {
+	width := 1
+	for _, v := range vals {
+		if w := bits.Len64(uint64(v^(v>>63))) + 1; w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// EncodeTokens codes values, as they are and with no delta stage, as
+// a stream of tokens for data that mixes long zero runs, well-behaved
+// stretches and bursts Exp-Golomb would expand.  The stream starts
+// with the value count as an unsigned codeword.  Each token is two
+// bits of type and its value count, less one, as an unsigned codeword,
+// followed by:  for a run of zeros, nothing; for ordinary values,
+// their sign-bit codewords; for a literal block, the bit width less
+// one in six raw bits and then each value in that many bits of two's
+// complement.
+//
+// Values are taken 32 at a time.  A window of zeros becomes a run;
+// any other window is coded whichever of the two other ways is
+// shorter, and neighbouring windows of the same kind share a token.
+// A window therefore never costs more than the shorter of its plain
+// codewords and its raw bits, plus one token's header.
+func EncodeTokens(w io.Writer, values []int) error {
+	tokens := make([]token, 0)
+	for i := 0; i < len(values); i += tokenWindow {
+		end := i + tokenWindow
+		if end > len(values) {
+			end = len(values)
+		}
+		win := values[i:end]
+		var t token
+		if width := literalWidth(win); width == 1 && allZero(win) {
+			t = token{kind: tokenZeros}
+		} else if 6+len(win)*width < EncodedLen(win) {
+			t = token{kind: tokenLiteral, width: width}
+		} else {
+			t = token{kind: tokenCodes}
+		}
+		if last := len(tokens) - 1; last >= 0 && tokens[last].kind == t.kind && tokens[last].width == t.width {
+			tokens[last].values = values[i-len(tokens[last].values) : i+len(win)]
+			continue
+		}
+		t.values = win
+		tokens = append(tokens, t)
+	}
+
+	egs := NewExpGolombEncoder(w)
+	egs.WriteUnsigned(uint(len(values)))
+	for _, t := range tokens {
+		egs.WriteRawBits(uint64(t.kind), 2)
+		egs.WriteUnsigned(uint(len(t.values) - 1))
+		switch t.kind {
+		case tokenCodes:
+			egs.Write(t.values)
+		case tokenLiteral:
+			egs.WriteRawBits(uint64(t.width-1), 6)
+			for _, v := range t.values {
+				egs.WriteRawBits(uint64(v), uint(t.width))
+			}
+		}
+	}
+	return egs.Close()
+}
+
+func allZero(vals []int) bool {
+	for _, v := range vals {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// DecodeTokens reverses EncodeTokens.  A stream that ends early gives
+// io.ErrUnexpectedEOF, and a token that is not valid gives ErrToken,
+// each along with the values decoded before it.
+func DecodeTokens(r io.Reader) ([]int, error) {
+	res := make([]int, 0)
+	decoder := NewExpGolombDecoder(r)
+	tmp := make([]uint, 1)
+	readUnsigned := func() (uint, error) {
+		if n, err := decoder.ReadUnsigned(tmp); n == 0 {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		return tmp[0], nil
+	}
+	readRaw := func(n uint) (uint64, error) {
+		v, err := decoder.ReadRawBits(n)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return v, err
+	}
+
+	total, err := readUnsigned()
+	if err != nil {
+		return res, err
+	}
+	if total > 1<<31 {
+		return res, ErrToken
+	}
+	for uint(len(res)) < total {
+		kind, err := readRaw(2)
+		if err != nil {
+			return res, err
+		}
+		count, err := readUnsigned()
+		if err != nil {
+			return res, err
+		}
+		if count >= total-uint(len(res)) {
+			return res, ErrToken
+		}
+		count++
+
+		switch kind {
+		case tokenZeros:
+			res = append(res, make([]int, count)...)
+		case tokenCodes:
+			vals := make([]int, count)
+			if err := decoder.ReadFull(vals); err != nil {
+				return res, err
+			}
+			res = append(res, vals...)
+		case tokenLiteral:
+			width, err := readRaw(6)
+			if err != nil {
+				return res, err
+			}
+			width++
+			for ; count > 0; count-- {
+				raw, err := readRaw(uint(width))
+				if err != nil {
+					return res, err
+				}
+				// Sign-extend from width.
+				res = append(res, int(raw<<(64-width))>>(64-width))
+			}
+		default:
+			return res, ErrToken
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeTokens(t *testing.T) {
+	// Zero runs, small residuals and bursts of wide noise, in
+	// stretches that don't line up with the windows.
+	values := make([]int, 0)
+	for i := 0; i < 40; i++ {
+		n := 1 + rand.Intn(150)
+		for j := 0; j < n; j++ {
+			switch i % 3 {
+			case 0:
+				values = append(values, 0)
+			case 1:
+				values = append(values, rand.Intn(9)-4)
+			case 2:
+				values = append(values, rand.Intn(1<<20)-1<<19)
+			}
+		}
+	}
+	values = append(values, -1<<63, 1<<63-1)
+
+	buf := &bytes.Buffer{}
+	if err := EncodeTokens(buf, values); err != nil {
+		t.Fatal(err)
+	}
+	res, err := DecodeTokens(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(values) {
+		t.Fatalf("Got %d values, want %d", len(res), len(values))
+	}
+	for i := range values {
+		if res[i] != values[i] {
+			t.Fatalf("Item %d was %d, expected %d", i, res[i], values[i])
+		}
+	}
+
+	// At worst a header per window over the plain codewords.
+	windows := (len(values) + tokenWindow - 1) / tokenWindow
+	bound := EncodedLen(values) + 64 + windows*(2+2*6+1+6)
+	if 8*buf.Len() > bound {
+		t.Fatalf("Tokens took %d bits, over the bound of %d", 8*buf.Len(), bound)
+	}
+	if plain := EncodedLen(values); 8*buf.Len() >= plain {
+		t.Fatalf("Tokens took %d bits, plain codewords %d", 8*buf.Len(), plain)
+	}
+
+	for _, vals := range [][]int{{}, {0}, {5}, make([]int, 1000)} {
+		buf.Reset()
+		EncodeTokens(buf, vals)
+		if res, err := DecodeTokens(buf); err != nil || len(res) != len(vals) {
+			t.Fatalf("%d values gave %d, %v", len(vals), len(res), err)
+		}
+	}
+
+	buf.Reset()
+	EncodeTokens(buf, values)
+	stream := buf.Bytes()
+	if _, err := DecodeTokens(bytes.NewReader(stream[:len(stream)/2])); err != io.ErrUnexpectedEOF {
+		t.Fatal("Expected io.ErrUnexpectedEOF, got ", err)
+	}
+	// A count of 2, then a token type of 3.
+	if _, err := DecodeTokens(bytes.NewReader([]byte{0x7c})); err != ErrToken {
+		t.Fatal("Expected ErrToken, got ", err)
+	}
+}
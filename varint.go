@@ -0,0 +1,55 @@
+package deltagolomb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+type varintReader struct {
+	decoder *ExpGolombDecoder
+	val     int
+	tmp     []int
+	buf     [binary.MaxVarintLen64]byte
+	pending []byte
+	err     error
+}
+
+// VarintReader adapts a delta-coded Exp-Golomb stream into an
+// io.Reader of varints.  Each decoded absolute value is written in
+// the encoding/binary signed varint format (zig-zag LEB128), so the
+// output can be read back with binary.ReadVarint.  Varints are
+// produced on demand and may be split across Read calls.
+func VarintReader(base int, compressed []byte) io.Reader {
+	return &varintReader{
+		decoder: NewExpGolombDecoder(bytes.NewBuffer(compressed)),
+		val:     base,
+		tmp:     make([]int, 1),
+	}
+}
+
+func (v *varintReader) Read(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		if len(v.pending) == 0 {
+			if v.err != nil {
+				break
+			}
+			n, err := v.decoder.Read(v.tmp)
+			v.err = err
+			if n == 0 {
+				continue
+			}
+			v.val += v.tmp[0]
+			l := binary.PutVarint(v.buf[:], int64(v.val))
+			v.pending = v.buf[:l]
+		}
+		c := copy(p[written:], v.pending)
+		v.pending = v.pending[c:]
+		written += c
+	}
+	if written > 0 {
+		return written, nil
+	}
+	return 0, v.err
+}
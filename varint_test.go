@@ -0,0 +1,43 @@
+package deltagolomb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestVarintReader(t *testing.T) {
+	data := []int{5, 300, -70000, -69999, 1 << 40, 0, 17}
+	base := 12
+	compressed := DeltaEncode(base, data)
+	want := DeltaDecode(base, compressed)
+
+	// OneByteReader forces every multi-byte varint across Read calls.
+	for _, r := range []io.Reader{
+		VarintReader(base, compressed),
+		iotest.OneByteReader(VarintReader(base, compressed)),
+	} {
+		br := bufio.NewReader(r)
+		got := make([]int, 0)
+		for {
+			v, err := binary.ReadVarint(br)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal("ReadVarint failed: ", err)
+			}
+			got = append(got, int(v))
+		}
+		if len(got) != len(want) {
+			t.Fatalf("Got %d values, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("item %d was %d, expected %d", i, got[i], want[i])
+			}
+		}
+	}
+}